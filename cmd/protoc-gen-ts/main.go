@@ -0,0 +1,44 @@
+// Command protoc-gen-ts is a protoc plugin that generates TypeScript. It reads
+// the request from standard input and writes the response to standard output.
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+
+	"github.com/toba/ts-protobuf/generator"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("protoc-gen-ts: ")
+
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal("error: reading input: ", err)
+	}
+
+	req := new(plugin.CodeGeneratorRequest)
+	if err := proto.Unmarshal(data, req); err != nil {
+		log.Fatal("error: parsing input proto: ", err)
+	}
+
+	// Problems with the request are reported to protoc in the response.
+	resp, _ := generator.Generate(req, generator.Options{
+		Logger: log.New(os.Stderr, "protoc-gen-ts: ", 0),
+	})
+
+	// Send back the results.
+	data, err = proto.Marshal(resp)
+	if err != nil {
+		log.Fatal("error: failed to marshal output proto: ", err)
+	}
+	_, err = os.Stdout.Write(data)
+	if err != nil {
+		log.Fatal("error: failed to write output proto: ", err)
+	}
+}
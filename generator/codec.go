@@ -0,0 +1,367 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Wire types of the protocol buffer binary format, keyed by the wire names
+// returned from TSType.
+var wireTypes = map[string]int{
+	"varint":   0,
+	"zigzag32": 0,
+	"zigzag64": 0,
+	"fixed64":  1,
+	"bytes":    2,
+	"group":    3,
+	"fixed32":  5,
+}
+
+// The wire type of packed repeated fields and of embedded messages.
+const wireBytes = 2
+
+// The wire type of the tag that ends a group.
+const wireEndGroup = 4
+
+// codecMethod returns the name of the runtime Reader and Writer methods that
+// decode and encode a scalar field.
+func codecMethod(field *descriptor.FieldDescriptorProto) string {
+	switch *field.Type {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		return "double"
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		return "float"
+	case descriptor.FieldDescriptorProto_TYPE_INT64:
+		return "int64"
+	case descriptor.FieldDescriptorProto_TYPE_UINT64:
+		return "uint64"
+	case descriptor.FieldDescriptorProto_TYPE_INT32,
+		descriptor.FieldDescriptorProto_TYPE_ENUM:
+		return "int32"
+	case descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		return "fixed64"
+	case descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		return "fixed32"
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return "bool"
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return "string"
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return "bytes"
+	case descriptor.FieldDescriptorProto_TYPE_UINT32:
+		return "uint32"
+	case descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		return "sfixed32"
+	case descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		return "sfixed64"
+	case descriptor.FieldDescriptorProto_TYPE_SINT32:
+		return "sint32"
+	case descriptor.FieldDescriptorProto_TYPE_SINT64:
+		return "sint64"
+	}
+	return ""
+}
+
+// isPacked returns whether a repeated field is written in packed encoding.
+func isPacked(message *messageDescriptor, field *descriptor.FieldDescriptorProto) bool {
+	if !isRepeated(field) || !isScalar(field) {
+		return false
+	}
+	if field.Options != nil && field.Options.Packed != nil {
+		return field.Options.GetPacked()
+	}
+	// Per https://developers.google.com/protocol-buffers/docs/proto3#simple:
+	// "In proto3, repeated fields of scalar numeric types use packed encoding by default."
+	return message.proto3()
+}
+
+// fieldTag returns the arguments of the Writer.tag call that starts the field.
+func (g *Generator) fieldTag(message *messageDescriptor, field *descriptor.FieldDescriptorProto) string {
+	_, wire := g.TSType(message, field)
+	return fmt.Sprintf("%d, %d", field.GetNumber(), wireTypes[wire])
+}
+
+// encodeValue returns the statement that writes the single value v of the
+// field, preceded by its tag. A group is followed by its END_GROUP tag
+// instead of being length-delimited.
+func (g *Generator) encodeValue(message *messageDescriptor, field *descriptor.FieldDescriptorProto, v string) string {
+	tag := "w.tag(" + g.fieldTag(message, field) + ")"
+	if name := wellKnown(field); name != "" {
+		return g.wellKnownCodec(name) + ".encode(" + g.wellKnownIn(name, v) + ", " + tag + ".fork()).ldelim();"
+	}
+	if *field.Type == descriptor.FieldDescriptorProto_TYPE_GROUP {
+		return g.encodeCall(g.TypeName(g.ObjectNamed(field.GetTypeName())), v, tag) + fmt.Sprintf(".tag(%d, %d);", field.GetNumber(), wireEndGroup)
+	}
+	if isMessage(field) {
+		return g.encodeCall(g.TypeName(g.ObjectNamed(field.GetTypeName())), v, tag+".fork()") + ".ldelim();"
+	}
+	if is64(field) {
+		v = g.longToBigInt(v)
+	}
+	return tag + "." + codecMethod(field) + "(" + v + ");"
+}
+
+// decodeValue returns the expression that reads a single value of the field.
+func (g *Generator) decodeValue(message *messageDescriptor, field *descriptor.FieldDescriptorProto) string {
+	if name := wellKnown(field); name != "" {
+		return g.wellKnownOut(name, g.wellKnownCodec(name)+".decode(r, r.uint32())")
+	}
+	if *field.Type == descriptor.FieldDescriptorProto_TYPE_GROUP {
+		// The reader checks the END_GROUP tag.
+		return fmt.Sprintf("r.group(%d, (length) => %s.decode(r, length))", field.GetNumber(), g.TypeName(g.ObjectNamed(field.GetTypeName())))
+	}
+	if isMessage(field) {
+		return g.TypeName(g.ObjectNamed(field.GetTypeName())) + ".decode(r, r.uint32())"
+	}
+	if is64(field) {
+		return g.longFromBigInt("r." + codecMethod(field) + "()")
+	}
+	return "r." + codecMethod(field) + "()"
+}
+
+// isZero returns the condition under which the value v of a singular field
+// is its zero value, which proto3 does not put on the wire.
+func (g *Generator) isZero(message *messageDescriptor, field *descriptor.FieldDescriptorProto, v string) string {
+	switch *field.Type {
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return "!" + v
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return v + ".length === 0"
+	case descriptor.FieldDescriptorProto_TYPE_GROUP,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		return v + " === undefined"
+	}
+	return v + " === " + g.zeroValue(message, field)
+}
+
+// generateEncode generates the method that writes the message in the binary
+// format.
+func (g *Generator) generateEncode(message *messageDescriptor) {
+	fieldNames := message.fieldNames()
+
+	g.P("/** Writes the message in the protocol buffer binary format. */")
+	g.methodHeader(message, false, "encode", "w: "+g.Pkg["runtime"]+".Writer = new "+g.Pkg["runtime"]+".Writer()", g.Pkg["runtime"]+".Writer")
+	for _, field := range message.Field {
+		if inOneof(field) {
+			if firstInOneof(message, field) {
+				oi := *field.OneofIndex
+				g.generateOneofSwitch(message, oi, "m."+message.oneofName(oi), func(field *descriptor.FieldDescriptorProto, v string) {
+					g.P(g.encodeValue(message, field, v))
+				})
+			}
+			continue
+		}
+		v := "m." + fieldNames[field]
+
+		switch {
+		case g.mapEntry(field) != nil:
+			entry := g.mapEntry(field)
+			keyField, valField := entry.Field[0], entry.Field[1]
+			g.P("for (const [k, v] of ", g.mapEntries(v), ") {")
+			g.In()
+			g.P("w.tag(", g.fieldTag(message, field), ").fork();")
+			g.P(g.encodeValue(entry, keyField, g.mapKey(entry, "k")))
+			g.P(g.encodeValue(entry, valField, "v"))
+			g.P("w.ldelim();")
+			g.Out()
+			g.P("}")
+		case isPacked(message, field):
+			g.P("if (", v, ".length > 0) {")
+			g.In()
+			g.P("w.tag(", field.Number, ", ", wireBytes, ").fork();")
+			g.P("for (const v of ", v, ") {")
+			g.In()
+			if is64(field) {
+				g.P("w.", codecMethod(field), "(", g.longToBigInt("v"), ");")
+			} else {
+				g.P("w.", codecMethod(field), "(v);")
+			}
+			g.Out()
+			g.P("}")
+			g.P("w.ldelim();")
+			g.Out()
+			g.P("}")
+		case isRepeated(field):
+			g.P("for (const v of ", v, ") {")
+			g.In()
+			g.P(g.encodeValue(message, field, "v"))
+			g.Out()
+			g.P("}")
+		case g.hasPresence(message, field):
+			ref := g.presenceRef(message, field, "m")
+			g.P("if (", ref, " !== undefined) {")
+			g.In()
+			g.P(g.encodeValue(message, field, ref))
+			g.Out()
+			g.P("}")
+		case isMessage(field), message.proto3():
+			// Proto3 scalars are only written when they differ from the zero value.
+			g.P("if (!(", g.isZero(message, field, v), ")) {")
+			g.In()
+			g.P(g.encodeValue(message, field, v))
+			g.Out()
+			g.P("}")
+		default:
+			g.P(g.encodeValue(message, field, v))
+		}
+	}
+	if g.unknown {
+		g.P("if (m.$unknown !== undefined) {")
+		g.In()
+		g.P("for (const data of m.$unknown) {")
+		g.In()
+		g.P("w.raw(data);")
+		g.Out()
+		g.P("}")
+		g.Out()
+		g.P("}")
+	}
+	if isExtendable(message) {
+		g.P("if (m.$extensions !== undefined) {")
+		g.In()
+		g.P(g.Pkg["runtime"], ".extension.write(m.$extensions, w);")
+		g.Out()
+		g.P("}")
+	}
+	g.P("return w;")
+	g.methodEnd()
+}
+
+// generateDecode generates the static method that reads the message from the
+// binary format.
+func (g *Generator) generateDecode(message *messageDescriptor) {
+	className := message.GetName()
+
+	g.P("/**")
+	g.P(" * Reads the message in the protocol buffer binary format. With a length,")
+	g.P(" * only that many bytes are read from the reader. Without one, missing")
+	g.P(" * required fields are reported as a RequiredFieldError.")
+	g.P(" */")
+	g.methodHeader(message, true, "decode", "input: "+g.Pkg["runtime"]+".Reader | Uint8Array, length?: number", className)
+	g.P("const r = ", g.Pkg["runtime"], ".Reader.create(input);")
+	g.P("const end = length === undefined ? r.len : r.pos + length;")
+	g.P("const m = ", g.construct(className), ";")
+	g.P("while (r.pos < end) {")
+	g.In()
+	if isExtendable(message) || g.unknown {
+		g.P("const start = r.pos;")
+	}
+	g.P("const t = r.uint32();")
+	g.P("switch (t >>> 3) {")
+	g.In()
+	for _, field := range message.Field {
+		v := g.assignRef(message, field, "m")
+
+		g.P("case ", field.Number, ":")
+		g.In()
+		switch {
+		case inOneof(field):
+			// The last field of a oneof on the wire wins.
+			g.P(oneofSet(field, "m."+message.oneofName(*field.OneofIndex), g.decodeValue(message, field)), ";")
+		case g.mapEntry(field) != nil:
+			g.generateDecodeMapEntry(field, v)
+		case isRepeated(field) && isScalar(field):
+			// Parsers must accept both packed and unpacked encodings.
+			g.P("if ((t & 7) === ", wireBytes, ") {")
+			g.In()
+			g.P("const packedEnd = r.uint32() + r.pos;")
+			g.P("while (r.pos < packedEnd) {")
+			g.In()
+			g.P(v, ".push(", g.decodeValue(message, field), ");")
+			g.Out()
+			g.P("}")
+			g.Out()
+			g.P("} else {")
+			g.In()
+			g.P(v, ".push(", g.decodeValue(message, field), ");")
+			g.Out()
+			g.P("}")
+		case isRepeated(field):
+			g.P(v, ".push(", g.decodeValue(message, field), ");")
+		default:
+			g.P(v, " = ", g.decodeValue(message, field), ";")
+		}
+		g.P("break;")
+		g.Out()
+	}
+	g.P("default:")
+	g.In()
+	g.P("r.skip(t & 7, t >>> 3);")
+	if isExtendable(message) {
+		// Extension fields are kept encoded, with their tags.
+		g.P("if (", g.Pkg["runtime"], ".extension.inRanges(", g.extendeeRef(message), ", t >>> 3)) {")
+		g.In()
+		g.P("m.$extensions = ", g.Pkg["runtime"], ".extension.add(m.$extensions, t >>> 3, r.buf.slice(start, r.pos));")
+		g.Out()
+		if g.unknown {
+			g.P("} else {")
+			g.In()
+			g.P("(m.$unknown ??= []).push(r.buf.slice(start, r.pos));")
+			g.Out()
+		}
+		g.P("}")
+	} else if g.unknown {
+		// Unknown fields are kept encoded, with their tags.
+		g.P("(m.$unknown ??= []).push(r.buf.slice(start, r.pos));")
+	}
+	g.Out()
+	g.Out()
+	g.P("}")
+	g.Out()
+	g.P("}")
+	g.generateDecodeCheck(message)
+	g.P("return m;")
+	g.methodEnd()
+}
+
+// generateDecodeMapEntry generates the statements that read one entry of the
+// map field held in v.
+func (g *Generator) generateDecodeMapEntry(field *descriptor.FieldDescriptorProto, v string) {
+	entry := g.mapEntry(field)
+	keyField, valField := entry.Field[0], entry.Field[1]
+
+	g.P("{")
+	g.In()
+	g.P("const entryEnd = r.uint32() + r.pos;")
+	g.P("let key = ", g.zeroValue(entry, keyField), ";")
+	valType, _ := g.TSType(entry, valField)
+	if isMessage(valField) {
+		g.P("let value: ", valType, " | undefined;")
+	} else {
+		g.P("let value: ", valType, " = ", g.zeroValue(entry, valField), ";")
+	}
+	g.P("while (r.pos < entryEnd) {")
+	g.In()
+	g.P("const et = r.uint32();")
+	g.P("switch (et >>> 3) {")
+	g.In()
+	g.P("case 1:")
+	g.In()
+	g.P("key = ", g.decodeValue(entry, keyField), ";")
+	g.P("break;")
+	g.Out()
+	g.P("case 2:")
+	g.In()
+	g.P("value = ", g.decodeValue(entry, valField), ";")
+	g.P("break;")
+	g.Out()
+	g.P("default:")
+	g.In()
+	g.P("r.skip(et & 7, et >>> 3);")
+	g.Out()
+	g.Out()
+	g.P("}")
+	g.Out()
+	g.P("}")
+	if name := wellKnown(valField); name != "" {
+		create := g.wellKnownOut(name, g.wellKnownCodec(name)+".create()")
+		g.P(g.mapSet(entry, v, "key", "value ?? "+create))
+	} else if isMessage(valField) {
+		g.P(g.mapSet(entry, v, "key", "value ?? "+g.construct(valType)))
+	} else {
+		g.P(g.mapSet(entry, v, "key", "value"))
+	}
+	g.Out()
+	g.P("}")
+}
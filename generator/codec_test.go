@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestCodec(t *testing.T) {
+	point := testMessage("Point",
+		testField("x", 1, optional, typeInt32),
+		testField("offset", 2, optional, typeSint64),
+		testField("ids", 3, repeated, typeInt32),
+		testField("label", 4, optional, typeString),
+		testTypedField("next", 5, typeMessage, ".test.Point"),
+	)
+	tests := []struct {
+		syntax string
+		want   []string
+	}{{
+		syntax: "proto3",
+		want: []string{
+			// Zero values are skipped, and repeated scalars packed.
+			"if (!(m.x === 0)) {",
+			"w.tag(2, 0).sint64(m.offset);",
+			"w.tag(3, 2).fork();",
+			"w.int32(v);",
+		},
+	}, {
+		syntax: "proto2",
+		want: []string{
+			// Singular fields have presence.
+			"if (m._x !== undefined) {",
+			"w.tag(1, 0).int32(m._x);",
+			"for (const v of m.ids) {",
+			"w.tag(3, 0).int32(v);",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.syntax, func(t *testing.T) {
+			out := generate(t, "", testFile("point.proto", tt.syntax, point))
+			wantContains(t, out, tt.want...)
+			wantContains(t, out,
+				"encode(w: pb.Writer = new pb.Writer()): pb.Writer {",
+				"m.next.encode(w.tag(5, 2).fork()).ldelim();",
+				"static decode(input: pb.Reader | Uint8Array, length?: number): Point {",
+				"m.offset = r.sint64();",
+				// Both encodings of repeated scalars are accepted.
+				"const packedEnd = r.uint32() + r.pos;",
+				"m.ids.push(r.int32());",
+				"m.next = Point.decode(r, r.uint32());",
+				"r.skip(t & 7, t >>> 3);",
+			)
+		})
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	file := testFile("point.proto", "proto3", testMessage("Point", testField("x", 1, optional, typeInt32)))
+	kept := []string{
+		"$unknown?: Uint8Array[];",
+		"const start = r.pos;",
+		"(m.$unknown ??= []).push(r.buf.slice(start, r.pos));",
+		"for (const data of m.$unknown) {\n\t\t\t\tw.raw(data);",
+	}
+
+	out := generate(t, "", file)
+	wantContains(t, out, kept...)
+
+	out = generate(t, "unknown_fields=false", file)
+	wantLacks(t, out, kept...)
+	wantContains(t, out, "r.skip(t & 7, t >>> 3);")
+}
+
+func TestGroups(t *testing.T) {
+	group := testMessage("SomeGroup", testField("group_field", 2, optional, typeInt32))
+	some := testTypedField("somegroup", 1, typeGroup, ".test.Message.SomeGroup")
+	message := testMessage("Message", some)
+	message.NestedType = []*descriptor.DescriptorProto{group}
+
+	out := generate(t, "", testFile("group.proto", "proto2", message))
+	wantContains(t, out,
+		"export class SomeGroup {",
+		"m.somegroup.encode(w.tag(1, 3)).tag(1, 4);",
+		"m.somegroup = r.group(1, (length) => Message.SomeGroup.decode(r, length));",
+		"r.skip(t & 7, t >>> 3);",
+	)
+	wantLacks(t, out, "w.tag(1, 2)")
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// extractLocations stores the locations of the elements of the file, by
+// path. An element declared in pieces, like the extend blocks of a file, has
+// several locations; the one with comments is kept, or else the first.
+func extractLocations(file *fileDescriptor) {
+	file.locations = make(map[string]*descriptor.SourceCodeInfo_Location)
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		var p []string
+		for _, n := range loc.Path {
+			p = append(p, strconv.Itoa(int(n)))
+		}
+		path := strings.Join(p, ",")
+		commented := loc.LeadingComments != nil || loc.TrailingComments != nil || len(loc.LeadingDetachedComments) > 0
+		if _, ok := file.locations[path]; !ok || commented {
+			file.locations[path] = loc
+		}
+	}
+}
+
+// commentParagraphs returns the comments of the location as paragraphs: the
+// detached comments before it, then the leading and trailing comments.
+func commentParagraphs(loc *descriptor.SourceCodeInfo_Location) []string {
+	var paragraphs []string
+	add := func(text string) {
+		var lines []string
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			lines = append(lines, strings.TrimRight(strings.TrimPrefix(line, " "), " \t"))
+		}
+		if text := strings.Trim(strings.Join(lines, "\n"), "\n"); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	for _, text := range loc.LeadingDetachedComments {
+		add(text)
+	}
+	add(loc.GetLeadingComments())
+	add(loc.GetTrailingComments())
+	return paragraphs
+}
+
+// PrintComments prints the comments of the element at the path of the source
+// .proto file as a JSDoc block, with a @deprecated tag if the element is
+// deprecated. The path is a comma-separated list of integers.
+// It returns an indication of whether anything was printed.
+// See descriptor.proto for its format.
+func (g *Generator) PrintComments(path string, deprecated bool) bool {
+	if !g.writeOutput {
+		return false
+	}
+	var lines []string
+	if loc, ok := g.file.locations[path]; ok {
+		for i, p := range commentParagraphs(loc) {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			// The comments must not end the block.
+			lines = append(lines, strings.Split(strings.Replace(p, "*/", "*\\/", -1), "\n")...)
+		}
+	}
+	if deprecated {
+		lines = append(lines, "@deprecated")
+	}
+	switch len(lines) {
+	case 0:
+		return false
+	case 1:
+		g.P("/** ", lines[0], " */")
+		return true
+	}
+	g.P("/**")
+	for _, line := range lines {
+		if line == "" {
+			g.P(" *")
+		} else {
+			g.P(" * ", line)
+		}
+	}
+	g.P(" */")
+	return true
+}
@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestComments(t *testing.T) {
+	hue := testField("hue", 1, optional, typeInt32)
+	shade := testField("shade", 2, optional, typeInt32)
+	shade.Options = &descriptor.FieldOptions{Deprecated: proto.Bool(true)}
+	file := testFile("color.proto", "proto3", testMessage("Color", hue, shade))
+	file.SourceCodeInfo = &descriptor.SourceCodeInfo{Location: []*descriptor.SourceCodeInfo_Location{{
+		Path:                    []int32{4, 0},
+		LeadingDetachedComments: []string{" Colors.\n"},
+		LeadingComments:         proto.String(" A color, such as */red.\n Or blue.\n"),
+	}, {
+		Path:             []int32{4, 0, 2, 0},
+		TrailingComments: proto.String(" no default\n"),
+	}}}
+
+	out := generate(t, "", file)
+	wantContains(t, out,
+		"/**\n * Colors.\n *\n * A color, such as *\\/red.\n * Or blue.\n */\nexport class Color {",
+		"\t/** no default */\n\thue: number = 0;",
+		"\t/** @deprecated */\n\tshade: number = 0;",
+	)
+	wantLacks(t, out, "// no default", "*/red")
+}
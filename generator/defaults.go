@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// The [default=...] of a proto2 field is a static member of the class, or a
+// constant in the namespace of an interface. The getter of the field returns
+// it while the field is unset.
+
+// defaultName returns the name of the constant holding the default of the
+// field, such as DEFAULT_DEADLINE.
+func defaultName(field *descriptor.FieldDescriptorProto) string {
+	return "DEFAULT_" + strings.ToUpper(field.GetName())
+}
+
+// defaultValue returns the TypeScript literal of the declared default of the
+// field, or "" if it has none.
+func (g *Generator) defaultValue(message *messageDescriptor, field *descriptor.FieldDescriptorProto) string {
+	if field.DefaultValue == nil || isRepeated(field) || inOneof(field) {
+		return ""
+	}
+	def := field.GetDefaultValue()
+	switch *field.Type {
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return def // "true" or "false"
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return jsQuote(def)
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		// protoc C-escapes the bytes.
+		var elems []string
+		for _, b := range []byte(unescape(def)) {
+			elems = append(elems, strconv.Itoa(int(b)))
+		}
+		return "new Uint8Array([" + strings.Join(elems, ", ") + "])"
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT,
+		descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		switch def {
+		case "inf":
+			return "Infinity"
+		case "-inf":
+			return "-Infinity"
+		case "nan":
+			return "NaN"
+		}
+		return def
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		// The default is the name of the value. Nested enums are declared
+		// after the class whose statics refer to them, so use the number.
+		enum := enumOf(g.ObjectNamed(field.GetTypeName()))
+		if enum == nil {
+			g.FailAt(g.fieldPath(field), "can't find enum for default of", field.GetName())
+		}
+		n := enum.integerValueAsString(def)
+		if n == "" {
+			g.FailAt(g.fieldPath(field), "cannot find value", def, "for default of", field.GetName())
+		}
+		return n + " /* " + def + " */"
+	}
+	if is64(field) {
+		switch g.long {
+		case longString:
+			return jsQuote(def)
+		case longBigInt:
+			return def + "n"
+		case longNumber:
+			// A number holds integers exactly only up to 2^53-1.
+			if !isSafeInteger(def) {
+				g.FailAt(g.fieldPath(field), "default", def, "of", field.GetName(), "is outside the safe integer range of long=number")
+			}
+		}
+	}
+	return def
+}
+
+// generateDefaultConstants generates the defaults of the fields of the
+// message as constants of its namespace, in interface mode.
+func (g *Generator) generateDefaultConstants(message *messageDescriptor) {
+	for _, field := range message.Field {
+		if def := g.defaultValue(message, field); def != "" {
+			typename, _ := g.TSType(message, field)
+			g.P("export const ", defaultName(field), ": ", typename, " = ", def, ";")
+		}
+	}
+}
+
+// jsQuote returns s as a TypeScript string literal.
+func jsQuote(s string) string {
+	// A JSON string is a valid literal, and escapes everything that is not.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
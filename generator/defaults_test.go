@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// withDefault returns the field with the [default=...] option, as protoc
+// writes it in the descriptor.
+func withDefault(f *descriptor.FieldDescriptorProto, def string) *descriptor.FieldDescriptorProto {
+	f.DefaultValue = proto.String(def)
+	return f
+}
+
+func TestDefaults(t *testing.T) {
+	fields := []*descriptor.FieldDescriptorProto{
+		withDefault(testField("deadline", 1, optional, typeDouble), "inf"),
+		withDefault(testField("name", 2, optional, typeString), `say "hi"`),
+		withDefault(testField("blob", 3, optional, typeBytes), `a\001\377`),
+		withDefault(testTypedField("color", 4, typeEnum, ".test.Color"), "GREEN"),
+		withDefault(testField("offset", 5, optional, typeSint64), "-9"),
+		withDefault(testField("on", 6, optional, typeBool), "true"),
+		testField("plain", 7, optional, typeInt32),
+	}
+	file := testFile("defaults.proto", "proto2", testMessage("Settings", fields...))
+	file.EnumType = append(file.EnumType, testEnum("Color", "RED", 0, "GREEN", 1))
+
+	out := generate(t, "", file)
+	wantContains(t, out,
+		"static readonly DEFAULT_DEADLINE: number = Infinity;",
+		`static readonly DEFAULT_NAME: string = "say \"hi\"";`,
+		"static readonly DEFAULT_BLOB: Uint8Array = new Uint8Array([97, 1, 255]);",
+		"static readonly DEFAULT_COLOR: Color = 1 /* GREEN */;",
+		"static readonly DEFAULT_OFFSET: bigint = -9n;",
+		"static readonly DEFAULT_ON: boolean = true;",
+		"private _deadline?: number;",
+		"get deadline(): number {",
+		"return this._deadline ?? Settings.DEFAULT_DEADLINE;",
+		"return this._plain ?? 0;",
+	)
+	wantLacks(t, out, "DEFAULT_PLAIN")
+
+	out = generate(t, "long=string", file)
+	wantContains(t, out, `static readonly DEFAULT_OFFSET: string = "-9";`)
+
+	out = generate(t, "mode=interfaces", file)
+	wantContains(t, out, "export const DEFAULT_DEADLINE: number = Infinity;")
+}
+
+func TestNumberDefaultsAreSafeIntegers(t *testing.T) {
+	safe := withDefault(testField("offset", 1, optional, typeSint64), "-9007199254740991")
+	out := generate(t, "long=number", testFile("defaults.proto", "proto2", testMessage("Settings", safe)))
+	wantContains(t, out, "static readonly DEFAULT_OFFSET: number = -9007199254740991;")
+
+	unsafe := withDefault(testField("offset", 1, optional, typeSint64), "9007199254740993")
+	file := testFile("defaults.proto", "proto2", testMessage("Settings", unsafe))
+	file.SourceCodeInfo = &descriptor.SourceCodeInfo{
+		Location: []*descriptor.SourceCodeInfo_Location{
+			{Path: []int32{messagePath, 0, messageFieldPath, 0}, Span: []int32{4, 2, 40}},
+		},
+	}
+	_, err := Generate(request("long=number", file), Options{})
+	want := "defaults.proto:5:3: default 9007199254740993 of offset is outside the safe integer range of long=number"
+	if err == nil || err.Error() != want {
+		t.Errorf("Generate returned error %v, want %q", err, want)
+	}
+
+	out = generate(t, "", file)
+	wantContains(t, out, "static readonly DEFAULT_OFFSET: bigint = 9007199254740993n;")
+}
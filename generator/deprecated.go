@@ -0,0 +1,109 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Deprecated elements have a @deprecated JSDoc tag, printed with their
+// comments. With the deprecated=warn parameter, setting a deprecated field
+// also logs a warning at run time, once per field.
+
+// warnsDeprecated returns whether setting the field logs a warning. Fields of
+// a oneof share a property, so they don't.
+func (g *Generator) warnsDeprecated(field *descriptor.FieldDescriptorProto) bool {
+	return g.warnDeprecated && field.GetOptions().GetDeprecated() && !inOneof(field)
+}
+
+// deprecationWarning returns the statement that warns that the field of the
+// message is set.
+func (g *Generator) deprecationWarning(message *messageDescriptor, field *descriptor.FieldDescriptorProto) string {
+	return g.Pkg["runtime"] + ".deprecated(" + strconv.Quote(message.fullName()+"."+field.GetName()) + ");"
+}
+
+// assignRef returns the expression through which the generated code of the
+// class assigns the field of the message m. It is the private property behind
+// the setter of a deprecated field that warns, so that decoding and
+// constructing do not warn; only assignments in other code do.
+func (g *Generator) assignRef(message *messageDescriptor, field *descriptor.FieldDescriptorProto, m string) string {
+	if g.warnsDeprecated(field) && !g.interfaces {
+		return m + "._" + message.fieldNames()[field]
+	}
+	return m + "." + message.fieldNames()[field]
+}
+
+// generateConstructor generates the constructor of the class, which assigns
+// the fields set by init. Deprecated fields that warn are assigned through
+// their private property.
+func (g *Generator) generateConstructor(message *messageDescriptor) {
+	var deprecated []*descriptor.FieldDescriptorProto
+	for _, field := range message.Field {
+		if g.warnsDeprecated(field) {
+			deprecated = append(deprecated, field)
+		}
+	}
+
+	g.P("constructor(init?: Partial<", message.GetName(), ">) {")
+	g.In()
+	g.P("if (init) {")
+	g.In()
+	if len(deprecated) == 0 {
+		g.P("Object.assign(this, init);")
+	} else {
+		var keys []string
+		for i, field := range deprecated {
+			keys = append(keys, strconv.Quote(message.fieldNames()[field])+": v"+strconv.Itoa(i))
+		}
+		g.P("const { ", strings.Join(keys, ", "), ", ...rest } = init;")
+		g.P("Object.assign(this, rest);")
+		for i, field := range deprecated {
+			v := "v" + strconv.Itoa(i)
+			g.P("if (", v, " !== undefined) {")
+			g.In()
+			g.P(g.assignRef(message, field, "this"), " = ", v, ";")
+			g.Out()
+			g.P("}")
+		}
+	}
+	g.Out()
+	g.P("}")
+	g.Out()
+	g.P("}")
+}
+
+// generateDeprecatedField generates a deprecated field of a class as a
+// private property with accessors, whose setter warns. The property starts
+// with the value init.
+func (g *Generator) generateDeprecatedField(message *messageDescriptor, field *descriptor.FieldDescriptorProto, typename, init, path string) {
+	name := message.fieldNames()[field]
+	g.P("private _", name, ": ", typename, " = ", init, ";")
+	g.PrintComments(path, true)
+	g.P("get ", name, "(): ", typename, " {")
+	g.In()
+	g.P("return this._", name, ";")
+	g.Out()
+	g.P("}")
+	g.P("set ", name, "(v: ", typename, ") {")
+	g.In()
+	g.P(g.deprecationWarning(message, field))
+	g.P("this._", name, " = v;")
+	g.Out()
+	g.P("}")
+}
+
+// generateCreateWarnings generates the statements of the create function of
+// an interface that warn about the deprecated fields set by init.
+func (g *Generator) generateCreateWarnings(message *messageDescriptor) {
+	for _, field := range message.Field {
+		if !g.warnsDeprecated(field) {
+			continue
+		}
+		g.P("if (init?.", message.fieldNames()[field], " !== undefined) {")
+		g.In()
+		g.P(g.deprecationWarning(message, field))
+		g.Out()
+		g.P("}")
+	}
+}
@@ -0,0 +1,45 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestDeprecated(t *testing.T) {
+	old := testField("old", 1, optional, typeInt32)
+	old.Options = &descriptor.FieldOptions{Deprecated: proto.Bool(true)}
+	legacy := testMessage("Legacy", old)
+	legacy.Options = &descriptor.MessageOptions{Deprecated: proto.Bool(true)}
+	ext := testField("flag", 100, optional, typeBool)
+	ext.Extendee = proto.String(".test.Legacy")
+	ext.Options = &descriptor.FieldOptions{Deprecated: proto.Bool(true)}
+	legacy.ExtensionRange = []*descriptor.DescriptorProto_ExtensionRange{{Start: proto.Int32(100), End: proto.Int32(200)}}
+	file := testFile("old.proto", "proto2", legacy)
+	file.Extension = []*descriptor.FieldDescriptorProto{ext}
+
+	out := generate(t, "", file)
+	wantContains(t, out,
+		"/** @deprecated */\nexport class Legacy {",
+		"/** @deprecated */\nexport const flag: pb.Extension<boolean> = {",
+	)
+	wantLacks(t, out, "pb.deprecated(")
+
+	out = generate(t, "deprecated=warn", file)
+	wantContains(t, out, `pb.deprecated("test.Legacy.old");`)
+}
+
+func TestDeprecatedFieldsWarnOnlyOnAssignment(t *testing.T) {
+	old := testField("old", 1, optional, typeInt32)
+	old.Options = &descriptor.FieldOptions{Deprecated: proto.Bool(true)}
+	out := generate(t, "deprecated=warn", testFile("old.proto", "proto3", testMessage("Legacy", old)))
+	wantContains(t, out,
+		"set old(v: number) {",
+		`pb.deprecated("test.Legacy.old");`,
+		`const { "old": v0, ...rest } = init;`,
+		"this._old = v0;",
+		"m._old = r.int32();",
+	)
+	wantLacks(t, out, "Object.assign(this, init);", "m.old = ")
+}
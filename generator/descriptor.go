@@ -1,4 +1,4 @@
-package main
+package generator
 
 import "github.com/golang/protobuf/protoc-gen-go/descriptor"
 
@@ -16,7 +16,6 @@ type (
 	// ProtoObject is an interface abstracting the abilities shared by enums,
 	// messages, extensions and imported objects.
 	ProtoObject interface {
-		PackageName() string // The name we use in our output (a_b_c), possibly renamed for uniqueness.
 		TypeName() []string
 		File() *descriptor.FileDescriptorProto
 	}
@@ -32,23 +31,23 @@ type (
 // See descriptor.proto for more information about this.
 const (
 	// tag numbers in FileDescriptorProto
-	packagePath = 2 // package
-	messagePath = 4 // message_type
-	enumPath    = 5 // enum_type
+	packagePath   = 2 // package
+	messagePath   = 4 // message_type
+	enumPath      = 5 // enum_type
+	servicePath   = 6 // service
+	extensionPath = 7 // extension
 	// tag numbers in DescriptorProto
-	messageFieldPath   = 2 // field
-	messageMessagePath = 3 // nested_type
-	messageEnumPath    = 4 // enum_type
-	messageOneofPath   = 8 // oneof_decl
+	messageFieldPath     = 2 // field
+	messageMessagePath   = 3 // nested_type
+	messageEnumPath      = 4 // enum_type
+	messageExtensionPath = 6 // extension
+	messageOneofPath     = 8 // oneof_decl
 	// tag numbers in EnumDescriptorProto
 	enumValuePath = 2 // value
+	// tag numbers in ServiceDescriptorProto
+	serviceMethodPath = 2 // method
 )
 
-// PackageName is name in the package clause in the generated file.
-func (c *common) PackageName() string {
-	return uniquePackageOf(c.file)
-}
-
 func (c *common) File() *descriptor.FileDescriptorProto {
 	return c.file
 }
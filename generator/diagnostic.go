@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// report records a problem with the element at the path of the current file,
+// prefixed with its location as protoc prints it: the file name, then the
+// line and column of the element if the file has source code info. A problem
+// with no current file, like a bad parameter, has no prefix.
+func (g *Generator) report(path, msg string) {
+	if prefix := g.location(path); prefix != "" {
+		msg = prefix + ": " + msg
+	}
+	g.diagnostics = append(g.diagnostics, msg)
+}
+
+// location returns the name of the current file followed by the line and
+// column where the element at the path starts, or the name alone if the
+// location of the element is unknown.
+func (g *Generator) location(path string) string {
+	if g.file == nil {
+		return ""
+	}
+	loc, ok := g.file.locations[path]
+	if path == "" || !ok || len(loc.Span) < 2 {
+		return g.file.GetName()
+	}
+	// Spans count lines and columns from zero.
+	return fmt.Sprintf("%s:%d:%d", g.file.GetName(), loc.Span[0]+1, loc.Span[1]+1)
+}
+
+// fieldPath returns the path of the field, or extension, in the current file,
+// or the empty string if it is not declared there.
+func (g *Generator) fieldPath(field *descriptor.FieldDescriptorProto) string {
+	if g.file == nil {
+		return ""
+	}
+	for _, message := range g.file.messages {
+		for i, f := range message.Field {
+			if f == field {
+				return fmt.Sprintf("%s,%d,%d", message.path, messageFieldPath, i)
+			}
+		}
+		for _, ext := range message.extensions {
+			if ext.FieldDescriptorProto == field {
+				return ext.path
+			}
+		}
+	}
+	for _, ext := range g.file.extensions {
+		if ext.FieldDescriptorProto == field {
+			return ext.path
+		}
+	}
+	return ""
+}
+
+// typeUsePath returns the path of the first field or method of the current
+// file that uses the named message or enum, or the empty string if there is
+// none.
+func (g *Generator) typeUsePath(typeName string) string {
+	if g.file == nil {
+		return ""
+	}
+	for _, message := range g.file.messages {
+		for _, field := range message.Field {
+			if field.GetTypeName() == typeName {
+				return g.fieldPath(field)
+			}
+		}
+		for _, ext := range message.extensions {
+			if ext.GetTypeName() == typeName {
+				return ext.path
+			}
+		}
+	}
+	for _, ext := range g.file.extensions {
+		if ext.GetTypeName() == typeName {
+			return ext.path
+		}
+	}
+	for i, service := range g.file.Service {
+		for j, method := range service.Method {
+			if method.GetInputType() == typeName || method.GetOutputType() == typeName {
+				return fmt.Sprintf("%d,%d,%d,%d", servicePath, i, serviceMethodPath, j)
+			}
+		}
+	}
+	return ""
+}
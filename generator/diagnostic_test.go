@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// brokenFile returns a file whose only field holds a message that does not
+// exist, declared on line 12, column 3.
+func brokenFile(name string) *descriptor.FileDescriptorProto {
+	f := testFile(name, "proto3", testMessage("Broken", testTypedField("thing", 1, typeMessage, ".nowhere.Thing")))
+	f.SourceCodeInfo = &descriptor.SourceCodeInfo{
+		Location: []*descriptor.SourceCodeInfo_Location{
+			{Path: []int32{messagePath, 0, messageFieldPath, 0}, Span: []int32{11, 2, 30}},
+		},
+	}
+	return f
+}
+
+func TestDiagnosticsAreLocated(t *testing.T) {
+	resp, err := Generate(request("", brokenFile("broken.proto")), Options{})
+	want := "broken.proto:12:3: can't find object with type .nowhere.Thing"
+	if err == nil || err.Error() != want {
+		t.Errorf("Generate returned error %v, want %q", err, want)
+	}
+	if resp.GetError() != want {
+		t.Errorf("response error = %q, want %q", resp.GetError(), want)
+	}
+	if len(resp.File) != 0 {
+		t.Errorf("response has %d files despite the error", len(resp.File))
+	}
+}
+
+func TestDependencyProblemsAreIgnored(t *testing.T) {
+	file := testFile("main.proto", "proto3", testMessage("Fine", testField("id", 1, optional, typeInt32)))
+	file.Dependency = []string{"broken.proto"}
+	resp, err := Generate(request("", brokenFile("broken.proto"), file), Options{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(resp.File) != 1 || resp.File[0].GetName() != "main.pb.ts" {
+		t.Errorf("response files = %v, want main.pb.ts", resp.File)
+	}
+	if resp.Error != nil {
+		t.Errorf("response error = %q, want none", resp.GetError())
+	}
+}
+
+func TestBadParametersAreAllReported(t *testing.T) {
+	file := testFile("ok.proto", "proto3", testMessage("Ok"))
+	_, err := Generate(request("mode=bogus,deprecated=loud,long=bigint", file), Options{})
+	want := "unknown deprecated loud\nunknown mode bogus"
+	if err == nil || err.Error() != want {
+		t.Errorf("Generate returned error %v, want %q", err, want)
+	}
+
+	g := NewGenerator()
+	g.try(func() {
+		g.CommandLineParameters("deprecated=loud")
+	})
+	if g.warnDeprecated {
+		t.Error("deprecated=loud turned on warnings")
+	}
+}
@@ -1,37 +1,35 @@
-package main
+package generator
 
 import (
 	"fmt"
-	"log"
 	"strconv"
-	"strings"
 
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 )
 
-// Generate the enum definitions for this EnumDescriptor.
+// Generate the enum definitions for this EnumDescriptor. Enums nested in a
+// message are generated within the namespace of that message.
 func (g *Generator) generateEnum(enum *enumDescriptor) {
-	// The full type name
-	typeName := enum.TypeName()
-	// The full type name, CamelCased.
-	ccTypeName := CamelCaseSlice(typeName)
-	ccPrefix := enum.prefix()
+	name := enum.GetName()
 
-	g.PrintComments(enum.path)
-	g.P("type ", ccTypeName, " int32")
-	g.file.addExport(enum, enumSymbol{ccTypeName, enum.proto3()})
-	g.P("const (")
+	kind := "enum "
+	if g.constEnums {
+		kind = "const enum "
+	}
+	g.PrintComments(enum.path, enum.GetOptions().GetDeprecated())
+	g.P("export ", kind, name, " {")
 	g.In()
 	for i, e := range enum.Value {
-		g.PrintComments(fmt.Sprintf("%s,%d,%d", enum.path, enumValuePath, i))
-
-		name := ccPrefix + *e.Name
-		g.P(name, " ", ccTypeName, " = ", e.Number)
-		g.file.addExport(enum, constOrVarSymbol{name, "const", ccTypeName})
+		g.PrintComments(fmt.Sprintf("%s,%d,%d", enum.path, enumValuePath, i), e.GetOptions().GetDeprecated())
+		g.P(*e.Name, " = ", e.Number, ",")
 	}
 	g.Out()
-	g.P(")")
-	g.P("var ", ccTypeName, "_name = map[int32]string{")
+	g.P("}")
+	g.P()
+
+	// Aliases allowed by allow_alias share a value, and an object literal
+	// may not repeat a key, so only the first name of each value is mapped.
+	g.P("export const ", name, "_name: { [value: number]: string } = {")
 	g.In()
 	generated := make(map[int32]bool) // avoid duplicate values
 	for _, e := range enum.Value {
@@ -43,71 +41,19 @@ func (g *Generator) generateEnum(enum *enumDescriptor) {
 		generated[*e.Number] = true
 	}
 	g.Out()
-	g.P("}")
-	g.P("var ", ccTypeName, "_value = map[string]int32{")
+	g.P("};")
+	g.P("export const ", name, "_value: { [name: string]: ", name, " } = {")
 	g.In()
 	for _, e := range enum.Value {
-		g.P(strconv.Quote(*e.Name), ": ", e.Number, ",")
+		g.P(strconv.Quote(*e.Name), ": ", name, ".", *e.Name, ",")
 	}
 	g.Out()
-	g.P("}")
-
-	if !enum.proto3() {
-		g.P("func (x ", ccTypeName, ") Enum() *", ccTypeName, " {")
-		g.In()
-		g.P("p := new(", ccTypeName, ")")
-		g.P("*p = x")
-		g.P("return p")
-		g.Out()
-		g.P("}")
-	}
-
-	g.P("func (x ", ccTypeName, ") String() string {")
-	g.In()
-	g.P("return ", g.Pkg["proto"], ".EnumName(", ccTypeName, "_name, int32(x))")
-	g.Out()
-	g.P("}")
-
-	if !enum.proto3() {
-		g.P("func (x *", ccTypeName, ") UnmarshalJSON(data []byte) error {")
-		g.In()
-		g.P("value, err := ", g.Pkg["proto"], ".UnmarshalJSONEnum(", ccTypeName, `_value, data, "`, ccTypeName, `")`)
-		g.P("if err != nil {")
-		g.In()
-		g.P("return err")
-		g.Out()
-		g.P("}")
-		g.P("*x = ", ccTypeName, "(value)")
-		g.P("return nil")
-		g.Out()
-		g.P("}")
-	}
-
-	var indexes []string
-	for m := enum.message; m != nil; m = m.parent {
-		// XXX: skip groups?
-		indexes = append([]string{strconv.Itoa(m.index)}, indexes...)
-	}
-	indexes = append(indexes, strconv.Itoa(enum.index))
-	g.P("func (", ccTypeName, ") EnumDescriptor() ([]byte, []int) { return ", g.file.VarName(), ", []int{", strings.Join(indexes, ", "), "} }")
-	if enum.file.GetPackage() == "google.protobuf" && enum.GetName() == "NullValue" {
-		g.P("func (", ccTypeName, `) XXX_WellKnownType() string { return "`, enum.GetName(), `" }`)
-	}
-
+	g.P("};")
 	g.P()
-}
 
-func (g *Generator) generateEnumRegistration(enum *enumDescriptor) {
-	// We always print the full (proto-world) package name here.
-	pkg := enum.File().GetPackage()
-	if pkg != "" {
-		pkg += "."
+	if enum.message == nil {
+		g.file.addExport(enum, enumSymbol{name})
 	}
-	// The full type name
-	typeName := enum.TypeName()
-	// The full type name, CamelCased.
-	ccTypeName := CamelCaseSlice(typeName)
-	g.addInitf("%s.RegisterEnum(%q, %[3]s_name, %[3]s_value)", g.Pkg["proto"], pkg+ccTypeName, ccTypeName)
 }
 
 func (g *Generator) buildNestedEnums(descs []*messageDescriptor, enums []*enumDescriptor) {
@@ -126,20 +72,15 @@ func (g *Generator) buildNestedEnums(descs []*messageDescriptor, enums []*enumDe
 }
 
 type enumSymbol struct {
-	name   string
-	proto3 bool // Whether this came from a proto3 file.
+	name string
 }
 
+// GenerateAlias re-exports the enum together with its name and value maps.
 func (es enumSymbol) GenerateAlias(g *Generator, pkg string) {
 	s := es.name
-	g.P("type ", s, " ", pkg, ".", s)
-	g.P("var ", s, "_name = ", pkg, ".", s, "_name")
-	g.P("var ", s, "_value = ", pkg, ".", s, "_value")
-	g.P("func (x ", s, ") String() string { return (", pkg, ".", s, ")(x).String() }")
-	if !es.proto3 {
-		g.P("func (x ", s, ") Enum() *", s, "{ return (*", s, ")((", pkg, ".", s, ")(x).Enum()) }")
-		g.P("func (x *", s, ") UnmarshalJSON(data []byte) error { return (*", pkg, ".", s, ")(x).UnmarshalJSON(data) }")
-	}
+	g.P("export import ", s, " = ", pkg, ".", s, ";")
+	g.P("export const ", s, "_name = ", pkg, ".", s, "_name;")
+	g.P("export const ", s, "_value = ", pkg, ".", s, "_value;")
 }
 
 // EnumDescriptor describes an enum. If it's at top level, its parent will be
@@ -205,24 +146,23 @@ func (e *enumDescriptor) TypeName() (s []string) {
 	return s
 }
 
-// Everything but the last element of the full type name, CamelCased.
-// The values of type Foo.Bar are call Foo_value1... not Foo_Bar_value1... .
-func (e *enumDescriptor) prefix() string {
-	if e.message == nil {
-		// If the enum is not part of a message, the prefix is just the type name.
-		return CamelCase(*e.Name) + "_"
+// enumOf returns the enum described by obj, looking through public imports.
+func enumOf(obj ProtoObject) *enumDescriptor {
+	if id, ok := obj.(*importDescriptor); ok {
+		// The enum type has been publicly imported.
+		obj = id.o
 	}
-	typeName := e.TypeName()
-	return CamelCaseSlice(typeName[0:len(typeName)-1]) + "_"
+	enum, _ := obj.(*enumDescriptor)
+	return enum
 }
 
-// The integer value of the named constant in this enumerated type.
+// The integer value of the named constant in this enumerated type, or the
+// empty string if there is none.
 func (e *enumDescriptor) integerValueAsString(name string) string {
 	for _, c := range e.Value {
 		if c.GetName() == name {
 			return fmt.Sprint(c.GetNumber())
 		}
 	}
-	log.Fatal("cannot find value for enum constant")
 	return ""
 }
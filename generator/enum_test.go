@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// testEnum returns an enum with the values, named and numbered in turn.
+func testEnum(name string, values ...interface{}) *descriptor.EnumDescriptorProto {
+	enum := &descriptor.EnumDescriptorProto{Name: proto.String(name)}
+	for i := 0; i < len(values); i += 2 {
+		enum.Value = append(enum.Value, &descriptor.EnumValueDescriptorProto{
+			Name:   proto.String(values[i].(string)),
+			Number: proto.Int32(int32(values[i+1].(int))),
+		})
+	}
+	return enum
+}
+
+func TestEnums(t *testing.T) {
+	days := testEnum("Days", "MONDAY", 1, "LUNDI", 1, "TUESDAY", 2)
+	days.Options = &descriptor.EnumOptions{AllowAlias: proto.Bool(true)}
+	color := testEnum("Color", "RED", 0, "GREEN", 1)
+	paint := testMessage("Paint", testTypedField("color", 1, typeEnum, ".test.Paint.Color"))
+	paint.EnumType = []*descriptor.EnumDescriptorProto{color}
+	file := testFile("enums.proto", "proto2", paint)
+	file.EnumType = []*descriptor.EnumDescriptorProto{days}
+
+	out := generate(t, "", file)
+	wantContains(t, out,
+		"export enum Days {",
+		"MONDAY = 1,",
+		"LUNDI = 1,",
+		`1: "MONDAY",`,
+		`// Duplicate value: 1: "LUNDI",`,
+		`"LUNDI": Days.LUNDI,`,
+		"export namespace Paint {",
+		"export enum Color {",
+		"return this._color ?? Paint.Color.RED;",
+	)
+	wantLacks(t, out, "const enum", "Days int32")
+
+	out = generate(t, "const_enums", file)
+	wantContains(t, out, "export const enum Days {", "export const enum Color {")
+}
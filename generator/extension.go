@@ -0,0 +1,248 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// ExtensionDescriptor describes an extension. If it's at top level, its
+// parent will be nil. Otherwise it will be the descriptor of the message in
+// which it is defined.
+type extensionDescriptor struct {
+	common
+	*descriptor.FieldDescriptorProto
+	message *messageDescriptor
+	path    string // The SourceCodeInfo path as comma-separated integers.
+}
+
+// TypeName returns the elements of the dotted type name.
+// The package name is not part of this name.
+func (e *extensionDescriptor) TypeName() (s []string) {
+	name := e.GetName()
+	if e.message == nil {
+		// top-level extension
+		s = make([]string, 1)
+	} else {
+		pname := e.message.TypeName()
+		s = make([]string, len(pname)+1)
+		copy(s, pname)
+	}
+	s[len(s)-1] = name
+	return s
+}
+
+// constName returns the name of the constant holding the descriptor, such as
+// userMessage. Nested extensions are reached through the namespace of their
+// message, as LoudMessage.volume.
+func (e *extensionDescriptor) constName() string {
+	if e.message != nil {
+		return e.message.extensionName(e)
+	}
+	return lowerCamelCase(e.GetName())
+}
+
+// fullName returns the fully-qualified proto name of the extension, such as
+// "extension_user.LoudMessage.volume".
+func (e *extensionDescriptor) fullName() string {
+	name := strings.Join(e.TypeName(), ".")
+	if pkg := e.File().GetPackage(); pkg != "" {
+		name = pkg + "." + name
+	}
+	return name
+}
+
+// Return a slice of all the top-level extensionDescriptors defined within this
+// file.
+func wrapExtensions(file *descriptor.FileDescriptorProto) []*extensionDescriptor {
+	var sl []*extensionDescriptor
+	for i, field := range file.Extension {
+		sl = append(sl, &extensionDescriptor{common{file}, field, nil, fmt.Sprintf("%d,%d", extensionPath, i)})
+	}
+	return sl
+}
+
+type extensionSymbol struct {
+	sym string
+}
+
+// GenerateAlias re-exports the extension descriptor.
+func (es extensionSymbol) GenerateAlias(g *Generator, pkg string) {
+	g.P("export const ", es.sym, " = ", pkg, ".", es.sym, ";")
+}
+
+// generateExtension generates the descriptor of the extension, which reads
+// and writes its value in the encoded extension fields of the extendee.
+func (g *Generator) generateExtension(ext *extensionDescriptor) {
+	field := ext.FieldDescriptorProto
+	runtime := g.Pkg["runtime"]
+	g.RecordTypeUse(*ext.Extendee)
+	if n := field.TypeName; n != nil && wellKnown(field) == "" {
+		// foreign extension type
+		g.RecordTypeUse(*n)
+	}
+
+	typ, _ := g.TSType(ext.message, field)
+	if isMessage(field) && !isRepeated(field) {
+		// Unset, as for fields.
+		typ += " | undefined"
+	}
+	defaultValue := g.defaultValue(ext.message, field)
+	if defaultValue == "" {
+		defaultValue = g.zeroValue(ext.message, field)
+	}
+	packed := isRepeated(field) && isScalar(field) && g.file.proto3
+	if field.Options != nil && field.Options.Packed != nil {
+		packed = field.Options.GetPacked()
+	}
+
+	g.PrintComments(ext.path, field.GetOptions().GetDeprecated())
+	g.P("export const ", ext.constName(), ": ", runtime, ".Extension<", typ, "> = {")
+	g.In()
+	g.P("name: ", strconv.Quote(ext.fullName()), ",")
+	g.P("extendee: ", strconv.Quote(strings.TrimPrefix(*ext.Extendee, ".")), ",")
+	g.P("fieldNo: ", field.Number, ",")
+	g.P("defaultValue: () => ", defaultValue, ",")
+	g.P("encode(v: ", typ, ", w: ", runtime, ".Writer): void {")
+	g.In()
+	switch {
+	case packed:
+		g.P("if (v.length > 0) {")
+		g.In()
+		g.P("w.tag(", field.Number, ", ", wireBytes, ").fork();")
+		g.P("for (const e of v) {")
+		g.In()
+		if is64(field) {
+			g.P("w.", codecMethod(field), "(", g.longToBigInt("e"), ");")
+		} else {
+			g.P("w.", codecMethod(field), "(e);")
+		}
+		g.Out()
+		g.P("}")
+		g.P("w.ldelim();")
+		g.Out()
+		g.P("}")
+	case isRepeated(field):
+		g.P("for (const e of v) {")
+		g.In()
+		g.P(g.encodeValue(ext.message, field, "e"))
+		g.Out()
+		g.P("}")
+	case isMessage(field):
+		g.P("if (v !== undefined) {")
+		g.In()
+		g.P(g.encodeValue(ext.message, field, "v"))
+		g.Out()
+		g.P("}")
+	default:
+		g.P(g.encodeValue(ext.message, field, "v"))
+	}
+	g.Out()
+	g.P("},")
+	switch {
+	case isRepeated(field) && isScalar(field):
+		// Parsers must accept both packed and unpacked encodings.
+		g.P("decode(r: ", runtime, ".Reader, t: number, v: ", typ, " = []): ", typ, " {")
+		g.In()
+		g.P("if ((t & 7) === ", wireBytes, ") {")
+		g.In()
+		g.P("const packedEnd = r.uint32() + r.pos;")
+		g.P("while (r.pos < packedEnd) {")
+		g.In()
+		g.P("v.push(", g.decodeValue(ext.message, field), ");")
+		g.Out()
+		g.P("}")
+		g.Out()
+		g.P("} else {")
+		g.In()
+		g.P("v.push(", g.decodeValue(ext.message, field), ");")
+		g.Out()
+		g.P("}")
+		g.P("return v;")
+		g.Out()
+		g.P("},")
+	case isRepeated(field):
+		g.P("decode(r: ", runtime, ".Reader, t: number, v: ", typ, " = []): ", typ, " {")
+		g.In()
+		g.P("v.push(", g.decodeValue(ext.message, field), ");")
+		g.P("return v;")
+		g.Out()
+		g.P("},")
+	default:
+		// The last occurrence wins.
+		g.P("decode(r: ", runtime, ".Reader): ", typ, " {")
+		g.In()
+		g.P("return ", g.decodeValue(ext.message, field), ";")
+		g.Out()
+		g.P("},")
+	}
+	g.Out()
+	g.P("};")
+	g.P()
+
+	if ext.message == nil {
+		g.file.addExport(ext, extensionSymbol{ext.constName()})
+	}
+}
+
+// isExtendable returns whether the message declares extension ranges.
+func isExtendable(message *messageDescriptor) bool {
+	return len(message.ExtensionRange) > 0
+}
+
+// extendee returns the expression of the pb.Extendee describing the
+// extension ranges of the message.
+func (g *Generator) extendee(message *messageDescriptor) string {
+	var ranges []string
+	for _, r := range message.ExtensionRange {
+		ranges = append(ranges, "["+strconv.Itoa(int(r.GetStart()))+", "+strconv.Itoa(int(r.GetEnd()))+"]")
+	}
+	return "{ name: " + strconv.Quote(message.fullName()) + ", ranges: [" + strings.Join(ranges, ", ") + "] }"
+}
+
+// extendeeRef returns the reference to the pb.Extendee of the message, from
+// within its methods.
+func (g *Generator) extendeeRef(message *messageDescriptor) string {
+	if g.interfaces {
+		return "extendee"
+	}
+	return message.GetName() + ".extendee"
+}
+
+// generateExtensionAccessors generates the methods of an extendable message
+// that get, set, test and clear its extensions. They check that the
+// extension extends the message, in one of its extension ranges.
+func (g *Generator) generateExtensionAccessors(message *messageDescriptor) {
+	runtime := g.Pkg["runtime"]
+	extendee := g.extendeeRef(message)
+	ext := "ext: " + runtime + ".Extension<T>"
+	anyExt := "ext: " + runtime + ".Extension<unknown>"
+
+	if g.interfaces {
+		g.P("const extendee: ", runtime, ".Extendee = ", g.extendee(message), ";")
+	} else {
+		g.P("private static readonly extendee: ", runtime, ".Extendee = ", g.extendee(message), ";")
+	}
+	g.P()
+	g.P("/** Returns the value of the extension, or its default if it is unset. */")
+	g.methodHeader(message, false, "getExtension<T>", ext, "T")
+	g.P("return ", runtime, ".extension.get(m.$extensions, ext, ", extendee, ");")
+	g.methodEnd()
+	g.P()
+	g.P("/** Sets the extension. Setting a message extension to undefined clears it. */")
+	g.methodHeader(message, false, "setExtension<T>", ext+", v: T", "void")
+	g.P("m.$extensions = ", runtime, ".extension.set(m.$extensions, ext, ", extendee, ", v);")
+	g.methodEnd()
+	g.P()
+	g.P("/** Returns whether the extension is set. */")
+	g.methodHeader(message, false, "hasExtension", anyExt, "boolean")
+	g.P("return ", runtime, ".extension.has(m.$extensions, ext, ", extendee, ");")
+	g.methodEnd()
+	g.P()
+	g.P("/** Clears the extension. */")
+	g.methodHeader(message, false, "clearExtension", anyExt, "void")
+	g.P(runtime, ".extension.clear(m.$extensions, ext, ", extendee, ");")
+	g.methodEnd()
+}
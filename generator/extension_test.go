@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestExtensions(t *testing.T) {
+	base := testMessage("Base")
+	base.ExtensionRange = []*descriptor.DescriptorProto_ExtensionRange{{Start: proto.Int32(100), End: proto.Int32(200)}}
+	user := testField("user", 100, optional, typeString)
+	user.Extendee = proto.String(".test.Base")
+	volume := testField("volume", 101, optional, typeInt32)
+	volume.Extendee = proto.String(".test.Base")
+	loud := testMessage("LoudMessage")
+	loud.Extension = []*descriptor.FieldDescriptorProto{volume}
+	f := testFile("ext.proto", "proto2", base, loud)
+	f.Extension = []*descriptor.FieldDescriptorProto{user}
+	out := generate(t, "", f)
+	wantContains(t, out,
+		"$extensions?: pb.ExtensionFields;",
+		"pb.extension.write(m.$extensions, w);",
+		"if (pb.extension.inRanges(Base.extendee, t >>> 3)) {\n\t\t\t\t\t\tm.$extensions = pb.extension.add(m.$extensions, t >>> 3, r.buf.slice(start, r.pos));",
+		"private static readonly extendee: pb.Extendee = { name: \"test.Base\", ranges: [[100, 200]] };",
+		"getExtension<T>(ext: pb.Extension<T>): T {",
+		"return pb.extension.has(m.$extensions, ext, Base.extendee);",
+		"export namespace LoudMessage {\n\texport const volume: pb.Extension<number> = {",
+		"name: \"test.LoudMessage.volume\",",
+		"w.tag(101, 0).int32(v);",
+		"export const user: pb.Extension<string> = {",
+		"extendee: \"test.Base\",",
+		"fieldNo: 100,",
+		"defaultValue: () => \"\",",
+	)
+	wantLacks(t, out, "class LoudMessage {\n\t$extensions", "ExtensionDesc")
+}
+
+func TestNestedExtensionsAvoidNamespaceNames(t *testing.T) {
+	base := testMessage("Base")
+	base.ExtensionRange = []*descriptor.DescriptorProto_ExtensionRange{{Start: proto.Int32(100), End: proto.Int32(200)}}
+	var exts []*descriptor.FieldDescriptorProto
+	for i, name := range []string{"decode", "extendee", "volume"} {
+		ext := testField(name, int32(100+i), optional, typeInt32)
+		ext.Extendee = proto.String(".test.Base")
+		exts = append(exts, ext)
+	}
+	holder := testMessage("Holder")
+	holder.Extension = exts
+	file := testFile("ext.proto", "proto2", base, holder)
+
+	out := generate(t, "mode=interfaces", file)
+	wantContains(t, out, "export const decode_: pb.Extension<number>", "export const extendee_: pb.Extension<number>", "export const volume: pb.Extension<number>", "/** Clears the extension. */")
+
+	out = generate(t, "", file)
+	wantContains(t, out, "export const decode: pb.Extension<number>", "export const extendee: pb.Extension<number>")
+}
@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"path"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// FileDescriptor describes a protocol buffer descriptor file (.proto). It
+// includes slices of all the messages and enums defined within it. Those slices
+// are constructed by WrapTypes.
+type fileDescriptor struct {
+	*descriptor.FileDescriptorProto
+	messages   []*messageDescriptor   // All the messages defined in this file.
+	enums      []*enumDescriptor      // All the enums defined in this file.
+	extensions []*extensionDescriptor // All the top-level extensions defined in this file.
+	imports    []*importDescriptor    // All types defined in files publicly imported by this file.
+
+	// Locations of the elements, with their comments, stored as a map of path
+	// (comma-separated integers) to the location.
+	locations map[string]*descriptor.SourceCodeInfo_Location
+
+	// The full list of symbols that are exported, as a map from the exported
+	// object to its symbols. This is used for supporting public imports.
+	exports map[ProtoObject][]symbol
+
+	index  int  // The index of this file in the list of files to generate code for
+	proto3 bool // whether to generate proto3 code for this file
+}
+
+// outputFileName returns the output name for the generated TypeScript file.
+func (d *fileDescriptor) outputFileName() string {
+	name := *d.Name
+	if ext := path.Ext(name); ext == ".proto" || ext == ".protodevel" {
+		name = name[:len(name)-len(ext)]
+	}
+	return name + ".pb.ts"
+}
+
+// moduleName returns the path other generated files use to import this one,
+// which is the output file name without its extension.
+func (d *fileDescriptor) moduleName() string {
+	return strings.TrimSuffix(d.outputFileName(), ".ts")
+}
+
+func (d *fileDescriptor) addExport(obj ProtoObject, sym symbol) {
+	d.exports[obj] = append(d.exports[obj], sym)
+}
+
+func fileIsProto3(file *descriptor.FileDescriptorProto) bool {
+	return file.GetSyntax() == "proto3"
+}
+
+// packageOf returns the alias under which the generated code of other files
+// imports the file. Each alias must be unique so it does not conflict in the
+// code we generate. SetPackageNames chooses them for the whole request
+// (although they don't have to be, it simplifies things).
+func (g *Generator) packageOf(fd *descriptor.FileDescriptorProto) string {
+	s, ok := g.packageNames[fd]
+	if !ok {
+		g.Fail("internal error: no package name defined for", fd.GetName())
+	}
+	return s
+}
+
+// GenerateAllFiles generates the output for all the files we're outputting.
+func (g *Generator) GenerateAllFiles() {
+	// Generate the output. The generator runs for every file, even the files
+	// that we don't generate output for, so that we can collate the full list
+	// of exported symbols to support public imports.
+	genFileMap := make(map[*fileDescriptor]bool, len(g.genFiles))
+	for _, file := range g.genFiles {
+		genFileMap[file] = true
+	}
+	// Fields declared optional in proto3 files are generated with presence.
+	g.Response.SupportedFeatures = proto.Uint64(uint64(plugin.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL))
+	// A problem abandons its file only, so that the problems of the other
+	// files are reported too. Dependencies are only generated for context:
+	// their problems are not ours to report, and only logged.
+	for _, file := range g.allFiles {
+		reported := len(g.diagnostics)
+		g.try(func() {
+			g.generateFile(file, genFileMap[file])
+		})
+		if !genFileMap[file] {
+			for _, d := range g.diagnostics[reported:] {
+				g.warnf("ignoring problem in dependency: %s", d)
+			}
+			g.diagnostics = g.diagnostics[:reported]
+		}
+		g.file = nil
+		g.init = nil
+		g.patterns = nil
+		g.indent = ""
+		g.method = nil
+	}
+}
+
+// generateFile adds the output for the file to the response, if it is to be
+// written.
+func (g *Generator) generateFile(file *fileDescriptor, writeOutput bool) {
+	g.Reset()
+	g.writeOutput = writeOutput
+	g.generate(file)
+	if !g.writeOutput {
+		return
+	}
+	g.Response.File = append(g.Response.File, &plugin.CodeGeneratorResponse_File{
+		Name:    proto.String(file.outputFileName()),
+		Content: proto.String(g.String()),
+	})
+	if g.wsrpc && len(file.Service) > 0 {
+		g.Reset()
+		g.generateWSDispatcher()
+		g.Response.File = append(g.Response.File, &plugin.CodeGeneratorResponse_File{
+			Name:    proto.String(file.wsDispatcherFileName()),
+			Content: proto.String(g.String()),
+		})
+	}
+}
+
+// FileOf return the FileDescriptor for this FileDescriptorProto.
+func (g *Generator) FileOf(fd *descriptor.FileDescriptorProto) *fileDescriptor {
+	for _, file := range g.allFiles {
+		if file.FileDescriptorProto == fd {
+			return file
+		}
+	}
+	g.Fail("could not find file in table:", fd.GetName())
+	return nil
+}
+
+func (g *Generator) fileByName(filename string) *fileDescriptor {
+	return g.allFilesByName[filename]
+}
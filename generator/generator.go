@@ -0,0 +1,413 @@
+// Package generator generates TypeScript from protocol buffer descriptors.
+// It holds the core of protoc-gen-ts: Generate takes the request protoc sends
+// to a plugin and returns the response protoc expects, so it can be embedded
+// in other programs.
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// Options configure Generate.
+type Options struct {
+	// Parameter replaces the parameter of the request, the comma-separated
+	// list of key=value pairs given to protoc with --ts_out, unless empty.
+	Parameter string
+	// Logger receives warnings. They are discarded if it is nil.
+	Logger *log.Logger
+}
+
+// Generate generates the TypeScript files of the request. Problems with the
+// request are reported in the error of the response, one per line, located
+// in their .proto file as protoc prints them; the response then has no
+// files, and the returned error holds the same text.
+func Generate(req *plugin.CodeGeneratorRequest, opts Options) (*plugin.CodeGeneratorResponse, error) {
+	g := NewGenerator()
+	g.Request = req
+	g.logger = opts.Logger
+	g.try(func() {
+		if len(req.FileToGenerate) == 0 {
+			g.Fail("no files to generate")
+		}
+		parameter := req.GetParameter()
+		if opts.Parameter != "" {
+			parameter = opts.Parameter
+		}
+		g.CommandLineParameters(parameter)
+
+		// Create a wrapped version of the Descriptors and EnumDescriptors that
+		// point to the file that defines them.
+		g.WrapTypes()
+
+		g.SetPackageNames()
+		g.BuildTypeNameMap()
+
+		g.GenerateAllFiles()
+	})
+
+	if len(g.diagnostics) > 0 {
+		s := strings.Join(g.diagnostics, "\n")
+		g.Response.Error = proto.String(s)
+		g.Response.File = nil
+		return g.Response, errors.New(s)
+	}
+	return g.Response, nil
+}
+
+// Generator is the type whose methods generate the output, stored in the
+// associated response structure.
+type Generator struct {
+	*bytes.Buffer
+
+	Request  *plugin.CodeGeneratorRequest  // The input.
+	Response *plugin.CodeGeneratorResponse // The output.
+
+	Parameter     map[string]string // Command-line parameters.
+	ImportPrefix  string            // String to prefix to imported module names.
+	ImportMap     map[string]string // Mapping from .proto file name to module specifier.
+	RuntimeModule string            // Module specifier of the runtime library.
+
+	Pkg map[string]string // The names under which we import support packages
+
+	logger *log.Logger // Where warnings go, or nil to discard them.
+
+	allFiles         []*fileDescriptor                          // All files in the tree
+	allFilesByName   map[string]*fileDescriptor                 // All files by filename.
+	genFiles         []*fileDescriptor                          // Those files we will generate output for.
+	file             *fileDescriptor                            // The file we are compiling now.
+	usedPackages     map[string]bool                            // Names of packages used in current file.
+	diagnostics      []string                                   // Problems found in the request, as protoc prints them.
+	packageNames     map[*descriptor.FileDescriptorProto]string // Import alias of each file.
+	pkgNamesInUse    map[string]bool                            // Import aliases already taken.
+	typeNameToObject map[string]ProtoObject                     // Key is a fully-qualified name in input syntax.
+	init             []string                                   // Statements to emit after all declarations.
+	patterns         map[string]string                          // Names of the RegExp constants of the current file, by pattern literal.
+	method           *openMethod                                // The codec method being generated, if any.
+	indent           string
+	writeOutput      bool
+
+	constEnums bool   // Generate const enums.
+	interfaces bool   // Generate interfaces and namespace functions instead of classes.
+	records    bool   // Generate map fields as records keyed by strings.
+	long       string // Representation of 64-bit integers.
+	presence   string // How fields track presence.
+	wsrpc      bool   // Generate WebSocket clients and Go dispatchers for services.
+	unknown    bool   // Keep unknown fields when decoding, and write them back.
+
+	warnDeprecated bool // Warn at run time when deprecated fields are set.
+}
+
+// new creates a new generator and allocates the request and response
+// protobufs.
+func NewGenerator() *Generator {
+	g := new(Generator)
+	g.Buffer = new(bytes.Buffer)
+	g.Request = new(plugin.CodeGeneratorRequest)
+	g.Response = new(plugin.CodeGeneratorResponse)
+	g.packageNames = make(map[*descriptor.FileDescriptorProto]string)
+	g.pkgNamesInUse = make(map[string]bool)
+	return g
+}
+
+// failure is the value Error and Fail panic with, once they have recorded the
+// problem, to abandon the generation of the current file. See try.
+type failure struct{}
+
+// Error reports a problem, including an error, and abandons the generation
+// of the current file.
+func (g *Generator) Error(err error, msgs ...string) {
+	g.ErrorAt("", err, msgs...)
+}
+
+// ErrorAt is like Error for a problem with the element at the path of the
+// current file, as FailAt is like Fail.
+func (g *Generator) ErrorAt(path string, err error, msgs ...string) {
+	g.report(path, strings.Join(msgs, " ")+": "+err.Error())
+	panic(failure{})
+}
+
+// Fail reports a problem and abandons the generation of the current file.
+func (g *Generator) Fail(msgs ...string) {
+	g.FailAt("", msgs...)
+}
+
+// FailAt reports a problem with the element at the path of the current file,
+// and abandons its generation. The path is a comma-separated list of
+// integers; see descriptor.proto for its format.
+func (g *Generator) FailAt(path string, msgs ...string) {
+	g.report(path, strings.Join(msgs, " "))
+	panic(failure{})
+}
+
+// try runs f, which stops at the first problem it reports.
+func (g *Generator) try(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(failure); !ok {
+				panic(r)
+			}
+		}
+	}()
+	f()
+}
+
+// warnf logs a warning about a problem that does not stop the generation.
+func (g *Generator) warnf(format string, a ...interface{}) {
+	if g.logger != nil {
+		g.logger.Printf("WARNING: "+format, a...)
+	}
+}
+
+// CommandLineParameters breaks the comma-separated list of key=value pairs
+// in the parameter (a member of the request protobuf) into a key/value map.
+// It then sets file name mappings defined by those entries.
+func (g *Generator) CommandLineParameters(parameter string) {
+	g.Parameter = make(map[string]string)
+	for _, p := range strings.Split(parameter, ",") {
+		if i := strings.Index(p, "="); i < 0 {
+			g.Parameter[p] = ""
+		} else {
+			g.Parameter[p[0:i]] = p[i+1:]
+		}
+	}
+
+	g.ImportMap = make(map[string]string)
+	g.RuntimeModule = defaultRuntimeModule
+	g.long = longBigInt
+	g.presence = presenceAccessors
+	g.unknown = true
+
+	// Bad parameters are reported in a stable order.
+	var keys []string
+	for k := range g.Parameter {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := g.Parameter[k]
+		switch k {
+		case "import_prefix":
+			g.ImportPrefix = v
+		case "runtime":
+			g.RuntimeModule = v
+		case "const_enums":
+			g.constEnums = v != "false"
+		case "mode":
+			switch v {
+			case "classes":
+				g.interfaces = false
+			case "interfaces":
+				g.interfaces = true
+			default:
+				g.report("", "unknown mode "+v)
+			}
+		case "maps":
+			switch v {
+			case "map":
+				g.records = false
+			case "record":
+				g.records = true
+			default:
+				g.report("", "unknown maps "+v)
+			}
+		case "long":
+			switch v {
+			case longBigInt, longString, longNumber:
+				g.long = v
+			default:
+				g.report("", "unknown long "+v)
+			}
+		case "presence":
+			switch v {
+			case presenceAccessors, presenceOptional:
+				g.presence = v
+			default:
+				g.report("", "unknown presence "+v)
+			}
+		case "wsrpc":
+			g.wsrpc = v != "false"
+		case "unknown_fields":
+			g.unknown = v != "false"
+		case "deprecated":
+			switch v {
+			case "warn":
+				g.warnDeprecated = true
+			default:
+				g.report("", "unknown deprecated "+v)
+			}
+		default:
+			if len(k) > 0 && k[0] == 'M' {
+				g.ImportMap[k[1:]] = v
+			}
+		}
+	}
+	// Report every bad parameter before giving up.
+	if len(g.diagnostics) > 0 {
+		panic(failure{})
+	}
+}
+
+// ObjectNamed, given a fully-qualified input type name as it appears in the input data,
+// returns the descriptor for the message or enum with that name.
+func (g *Generator) ObjectNamed(typeName string) ProtoObject {
+	o, ok := g.typeNameToObject[typeName]
+	if !ok {
+		g.FailAt(g.typeUsePath(typeName), "can't find object with type", typeName)
+	}
+
+	// If the file of this object isn't a direct dependency of the current file,
+	// or in the current file, then this object has been publicly imported into
+	// a dependency of the current file.
+	// We should return the ImportedDescriptor object for it instead.
+	direct := *o.File().Name == *g.file.Name
+	if !direct {
+		for _, dep := range g.file.Dependency {
+			if *g.fileByName(dep).Name == *o.File().Name {
+				direct = true
+				break
+			}
+		}
+	}
+	if !direct {
+		found := false
+	Loop:
+		for _, dep := range g.file.Dependency {
+			df := g.fileByName(*g.fileByName(dep).Name)
+			for _, td := range df.imports {
+				if td.o == o {
+					// Found it!
+					o = td
+					found = true
+					break Loop
+				}
+			}
+		}
+		if !found {
+			g.warnf("failed finding publicly imported dependency for %v, used in %v", typeName, *g.file.Name)
+		}
+	}
+
+	return o
+}
+
+// addInitf stores the given statement to be printed at the end of the file,
+// once every class and namespace it might refer to has been declared.
+// The statement is given as a format specifier and arguments.
+func (g *Generator) addInitf(stmt string, a ...interface{}) {
+	g.init = append(g.init, fmt.Sprintf(stmt, a...))
+}
+
+// Fill the response protocol buffer with the generated output for all the files we're
+// supposed to generate.
+func (g *Generator) generate(file *fileDescriptor) {
+	g.file = g.FileOf(file.FileDescriptorProto)
+	g.usedPackages = make(map[string]bool)
+
+	for _, td := range g.file.imports {
+		g.generateImported(td)
+	}
+	for _, enum := range g.file.enums {
+		// Nested enums are generated within the namespace of their message.
+		if enum.message != nil {
+			continue
+		}
+		g.generateEnum(enum)
+	}
+	for _, desc := range g.file.messages {
+		// Nested messages are generated within the namespace of their parent.
+		if desc.parent != nil {
+			continue
+		}
+		g.generateMessage(desc)
+	}
+	for _, ext := range g.file.extensions {
+		g.generateExtension(ext)
+	}
+	for i, service := range g.file.Service {
+		g.generateService(service, i)
+	}
+	g.generateInitStatements()
+
+	// Generate header and imports last, though they appear first in the output.
+	rem := g.Buffer
+	g.Buffer = new(bytes.Buffer)
+	g.generateHeader()
+	g.generateImports()
+	if !g.writeOutput {
+		return
+	}
+	g.Write(rem.Bytes())
+}
+
+// Generate the header, including the leading comments of the package
+// statement.
+func (g *Generator) generateHeader() {
+	g.P("// Code generated by protoc-gen-ts. DO NOT EDIT.")
+	g.P("// source: ", g.file.Name)
+	g.P()
+
+	var topMsgs []string
+	for _, msg := range g.file.messages {
+		if msg.parent != nil {
+			continue
+		}
+		topMsgs = append(topMsgs, msg.GetName())
+	}
+	loc, hasComments := g.file.locations[strconv.Itoa(packagePath)]
+	hasComments = hasComments && loc.LeadingComments != nil
+	if !hasComments && len(topMsgs) == 0 {
+		return
+	}
+
+	g.P("/*")
+	if hasComments {
+		// not using g.PrintComments because this is a /* */ comment block.
+		text := strings.TrimSuffix(loc.GetLeadingComments(), "\n")
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimPrefix(line, " ")
+			// ensure we don't escape from the block comment
+			line = strings.Replace(line, "*/", "* /", -1)
+			g.P(line)
+		}
+		g.P()
+	}
+	if len(topMsgs) > 0 {
+		g.P("It has these top-level messages:")
+		for _, msg := range topMsgs {
+			g.P("\t", msg)
+		}
+	}
+	g.P("*/")
+	g.P()
+}
+
+// weak returns whether the ith import of the current file is a weak import.
+func (g *Generator) weak(i int32) bool {
+	for _, j := range g.file.WeakDependency {
+		if j == i {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Generator) generateInitStatements() {
+	if len(g.init) == 0 {
+		return
+	}
+	for _, l := range g.init {
+		g.P(l)
+	}
+	g.P()
+	g.init = nil
+}
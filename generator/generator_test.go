@@ -0,0 +1,252 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+// Shorthands for the labels and types of fields.
+const (
+	optional = descriptor.FieldDescriptorProto_LABEL_OPTIONAL
+	required = descriptor.FieldDescriptorProto_LABEL_REQUIRED
+	repeated = descriptor.FieldDescriptorProto_LABEL_REPEATED
+
+	typeBool    = descriptor.FieldDescriptorProto_TYPE_BOOL
+	typeBytes   = descriptor.FieldDescriptorProto_TYPE_BYTES
+	typeDouble  = descriptor.FieldDescriptorProto_TYPE_DOUBLE
+	typeInt32   = descriptor.FieldDescriptorProto_TYPE_INT32
+	typeInt64   = descriptor.FieldDescriptorProto_TYPE_INT64
+	typeSint64  = descriptor.FieldDescriptorProto_TYPE_SINT64
+	typeString  = descriptor.FieldDescriptorProto_TYPE_STRING
+	typeEnum    = descriptor.FieldDescriptorProto_TYPE_ENUM
+	typeGroup   = descriptor.FieldDescriptorProto_TYPE_GROUP
+	typeMessage = descriptor.FieldDescriptorProto_TYPE_MESSAGE
+)
+
+// testField returns a field of a scalar type.
+func testField(name string, number int32, label descriptor.FieldDescriptorProto_Label, typ descriptor.FieldDescriptorProto_Type) *descriptor.FieldDescriptorProto {
+	return &descriptor.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    label.Enum(),
+		Type:     typ.Enum(),
+		JsonName: proto.String(lowerCamelCase(name)),
+	}
+}
+
+// testTypedField returns a field of the type, a message or an enum, with the
+// full name.
+func testTypedField(name string, number int32, typ descriptor.FieldDescriptorProto_Type, typeName string) *descriptor.FieldDescriptorProto {
+	f := testField(name, number, optional, typ)
+	f.TypeName = proto.String(typeName)
+	return f
+}
+
+// testMessage returns a message with the fields.
+func testMessage(name string, fields ...*descriptor.FieldDescriptorProto) *descriptor.DescriptorProto {
+	return &descriptor.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+// testFile returns a file of the package test with the syntax, proto2 or
+// proto3, declaring the messages.
+func testFile(name, syntax string, messages ...*descriptor.DescriptorProto) *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:        proto.String(name),
+		Package:     proto.String("test"),
+		Syntax:      proto.String(syntax),
+		MessageType: messages,
+	}
+}
+
+// request returns the request to generate the last of the files with the
+// parameter. The other files are its dependencies.
+func request(parameter string, files ...*descriptor.FileDescriptorProto) *plugin.CodeGeneratorRequest {
+	return &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{files[len(files)-1].GetName()},
+		Parameter:      proto.String(parameter),
+		ProtoFile:      files,
+	}
+}
+
+// generateFiles returns the content of every file generated for the last of
+// the files with the parameter, by name, failing the test on any problem.
+func generateFiles(t *testing.T, parameter string, files ...*descriptor.FileDescriptorProto) map[string]string {
+	t.Helper()
+	resp, err := Generate(request(parameter, files...), Options{})
+	if err != nil {
+		t.Fatalf("Generate(%q): %v", parameter, err)
+	}
+	out := make(map[string]string)
+	for _, f := range resp.File {
+		out[f.GetName()] = f.GetContent()
+	}
+	return out
+}
+
+// generate returns the TypeScript generated for the last of the files with
+// the parameter.
+func generate(t *testing.T, parameter string, files ...*descriptor.FileDescriptorProto) string {
+	t.Helper()
+	for name, content := range generateFiles(t, parameter, files...) {
+		if strings.HasSuffix(name, ".pb.ts") {
+			return content
+		}
+	}
+	t.Fatalf("generate(%q) returned no TypeScript file", parameter)
+	return ""
+}
+
+// wantContains fails the test unless the generated code has every snippet.
+func wantContains(t *testing.T, out string, snippets ...string) {
+	t.Helper()
+	for _, s := range snippets {
+		if !strings.Contains(out, s) {
+			t.Errorf("output lacks %q:\n%s", s, out)
+		}
+	}
+}
+
+// wantLacks fails the test if the generated code has any of the snippets.
+func wantLacks(t *testing.T, out string, snippets ...string) {
+	t.Helper()
+	for _, s := range snippets {
+		if strings.Contains(out, s) {
+			t.Errorf("output has %q:\n%s", s, out)
+		}
+	}
+}
+
+// sampleFiles returns a proto2 file whose message has fields with presence,
+// a 64-bit integer and a map, and a proto3 file with a plain scalar.
+func sampleFiles() (proto2, proto3 *descriptor.FileDescriptorProto) {
+	sample := testMessage("Sample",
+		testField("count", 1, optional, typeInt32),
+		testField("id", 2, optional, typeInt64),
+	)
+	addMap(sample, "counts", 3, typeString, typeInt32)
+	plain := testMessage("Plain", testField("total", 1, optional, typeInt32))
+	return testFile("sample.proto", "proto2", sample), testFile("plain.proto", "proto3", plain)
+}
+
+func TestGenerate(t *testing.T) {
+	sample, plain := sampleFiles()
+	tests := []struct {
+		name      string
+		parameter string
+		file      *descriptor.FileDescriptorProto
+		want      []string
+		lacks     []string
+	}{{
+		name: "defaults",
+		file: sample,
+		want: []string{
+			"export class Sample {",
+			"private _count?: number;",
+			"hasCount(): boolean {",
+			"clearCount(): void {",
+			"private _id?: bigint;",
+			"counts: Map<string, number> = new Map();",
+			"if (m._count !== undefined) {",
+			"w.tag(1, 0).int32(m._count);",
+			"w.tag(2, 0).int64(m._id);",
+			"for (const [k, v] of m.counts) {",
+			"m.id = r.int64();",
+			"$unknown?: Uint8Array[];",
+			`pb.registry.register("test.Sample", Sample);`,
+		},
+	}, {
+		name:      "optional presence",
+		parameter: "presence=optional",
+		file:      sample,
+		want:      []string{"count?: number;", "if (m.count !== undefined) {", "w.tag(1, 0).int32(m.count);"},
+		lacks:     []string{"private _count", "hasCount"},
+	}, {
+		name:      "interfaces",
+		parameter: "mode=interfaces",
+		file:      sample,
+		want: []string{
+			"export interface Sample {",
+			"count?: number;",
+			"export namespace Sample {",
+			"export function encode(m: Sample, w: pb.Writer = new pb.Writer()): pb.Writer {",
+			"const m = Sample.create();",
+		},
+		lacks: []string{"export class Sample", "hasCount"},
+	}, {
+		name:      "string longs",
+		parameter: "long=string",
+		file:      sample,
+		want:      []string{"private _id?: string;", "w.tag(2, 0).int64(pb.Long.toBigInt(m._id));", "m.id = r.int64().toString();"},
+	}, {
+		name:      "number longs",
+		parameter: "long=number",
+		file:      sample,
+		want:      []string{"private _id?: number;", "m.id = pb.Long.toNumber(r.int64());"},
+	}, {
+		name:      "record maps",
+		parameter: "maps=record",
+		file:      sample,
+		want:      []string{"counts: Record<string, number> = {};", "for (const [k, v] of Object.entries(m.counts)) {"},
+		lacks:     []string{"new Map()"},
+	}, {
+		name:      "no unknown fields",
+		parameter: "unknown_fields=false",
+		file:      sample,
+		lacks:     []string{"$unknown"},
+	}, {
+		name:  "proto3 zero values",
+		file:  plain,
+		want:  []string{"total: number = 0;", "if (!(m.total === 0)) {", "w.tag(1, 0).int32(m.total);"},
+		lacks: []string{"hasTotal", "_total"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := generate(t, tt.parameter, tt.file)
+			wantContains(t, out, tt.want...)
+			wantLacks(t, out, tt.lacks...)
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	sample, _ := sampleFiles()
+	orphan := testFile("orphan.proto", "proto3", testMessage("Orphan"))
+	orphan.Dependency = []string{"gone.proto"}
+	unlocated := brokenFile("unlocated.proto")
+	unlocated.SourceCodeInfo = nil
+
+	tests := []struct {
+		name      string
+		parameter string
+		files     []*descriptor.FileDescriptorProto
+		want      string
+	}{{
+		name:      "bad mode",
+		parameter: "mode=bogus",
+		files:     []*descriptor.FileDescriptorProto{sample},
+		want:      "unknown mode bogus",
+	}, {
+		name:  "missing dependency",
+		files: []*descriptor.FileDescriptorProto{orphan},
+		want:  "missing dependency gone.proto of orphan.proto",
+	}, {
+		name:  "unlocated type",
+		files: []*descriptor.FileDescriptorProto{unlocated},
+		want:  "unlocated.proto: can't find object with type .nowhere.Thing",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Generate(request(tt.parameter, tt.files...), Options{})
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Generate returned error %v, want %q", err, tt.want)
+			}
+			if resp.GetError() != tt.want {
+				t.Errorf("response error = %q, want %q", resp.GetError(), tt.want)
+			}
+		})
+	}
+}
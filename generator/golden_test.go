@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var update = flag.Bool("update", false, "write the golden files of the testdata")
+
+// goldenFiles are the .proto files of the testdata whose generated TypeScript
+// is compared with a golden file, such as testdata/imp.pb.ts.golden.
+var goldenFiles = []string{
+	"extension_base.proto",
+	"extension_extra.proto",
+	"extension_user.proto",
+	"grpc.proto",
+	"imp.proto",
+	"multi/multi1.proto",
+	"my_test/test.proto",
+	"proto3.proto",
+}
+
+// compileTestdata compiles the .proto files of the testdata, with their
+// comments, returning them and their dependencies in dependency order.
+func compileTestdata(t *testing.T, names ...string) []*descriptor.FileDescriptorProto {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver:       protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{"../testdata"}}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	compiled, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []*descriptor.FileDescriptorProto
+	seen := make(map[string]bool)
+	var add func(f protoreflect.FileDescriptor)
+	add = func(f protoreflect.FileDescriptor) {
+		if seen[f.Path()] {
+			return
+		}
+		seen[f.Path()] = true
+		imports := f.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		files = append(files, protodesc.ToFileDescriptorProto(f))
+	}
+	for _, f := range compiled {
+		add(f.(linker.File))
+	}
+	return files
+}
+
+func TestGolden(t *testing.T) {
+	files := compileTestdata(t, goldenFiles...)
+	for _, name := range goldenFiles {
+		t.Run(name, func(t *testing.T) {
+			req := &plugin.CodeGeneratorRequest{
+				FileToGenerate: []string{name},
+				Parameter:      proto.String(""),
+				ProtoFile:      files,
+			}
+			resp, err := Generate(req, Options{})
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if len(resp.File) != 1 {
+				t.Fatalf("Generate returned %d files, want 1", len(resp.File))
+			}
+			f := resp.File[0]
+			golden := filepath.Join("../testdata", f.GetName()+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(f.GetContent()), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v; run go test with -update to write it", err)
+			}
+			if f.GetContent() != string(want) {
+				t.Errorf("%s differs from %s; run go test with -update and review the diff", f.GetName(), golden)
+			}
+		})
+	}
+}
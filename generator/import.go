@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// importDescriptor describes a type that has been publicly imported from
+// another file.
+type importDescriptor struct {
+	common
+	o ProtoObject
+}
+
+func (id *importDescriptor) TypeName() []string {
+	return id.o.TypeName()
+}
+
+// defaultRuntimeModule is the module specifier of the TypeScript runtime
+// library in runtime/, the only module generated code depends on besides
+// other generated files.
+const defaultRuntimeModule = "ts-protobuf"
+
+// Generate the imports
+func (g *Generator) generateImports() {
+	g.P("import * as ", g.Pkg["runtime"], " from ", strconv.Quote(g.RuntimeModule), ";")
+	for i, s := range g.file.Dependency {
+		fd := g.fileByName(s)
+		spec := g.importSpecifier(fd)
+		// Skip weak imports.
+		if g.weak(int32(i)) {
+			g.P("// skipping weak import ", g.packageOf(fd.FileDescriptorProto), " ", strconv.Quote(spec))
+			continue
+		}
+		// We need to import all the dependencies, even if we don't reference them,
+		// because other code and tools depend on having the full transitive closure
+		// of protocol buffer types loaded.
+		if _, ok := g.usedPackages[g.packageOf(fd.FileDescriptorProto)]; !ok {
+			if wellKnownFiles[s] {
+				// Its messages are converted by the runtime.
+				continue
+			}
+			g.P("import ", strconv.Quote(spec), ";")
+			continue
+		}
+		g.P("import * as ", g.packageOf(fd.FileDescriptorProto), " from ", strconv.Quote(spec), ";")
+	}
+	g.P()
+	g.P("// This is a compile-time assertion to ensure that this generated file")
+	g.P("// is compatible with the runtime it is being compiled against.")
+	g.P("// A compilation error at this line likely means your copy of the")
+	g.P("// runtime needs to be updated.")
+	g.P(g.Pkg["runtime"], ".packageIsVersion1;")
+	g.P()
+}
+
+// importSpecifier returns the module specifier the current file uses to
+// import the generated output of fd. By default this is the path relative to
+// the current file; an import prefix or M mapping overrides it.
+func (g *Generator) importSpecifier(fd *fileDescriptor) string {
+	if substitution, ok := g.ImportMap[fd.GetName()]; ok {
+		return g.ImportPrefix + substitution
+	}
+	if g.ImportPrefix != "" {
+		return g.ImportPrefix + fd.moduleName()
+	}
+	rel, err := filepath.Rel(path.Dir(g.file.moduleName()), fd.moduleName())
+	if err != nil {
+		g.Error(err, "resolving import of", fd.GetName())
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+func (g *Generator) generateImported(id *importDescriptor) {
+	// Every generated file is a module of its own, so publicly imported
+	// symbols are re-exported even when we generate their file as well.
+	df := g.FileOf(id.o.File())
+	syms := df.exports[id.o]
+	if len(syms) == 0 {
+		// Nested types are reached through their exported parent.
+		return
+	}
+	tn := id.TypeName()
+	sn := tn[len(tn)-1]
+	filename := *df.Name
+	g.P("// ", sn, " from public import ", filename)
+	g.usedPackages[g.packageOf(df.FileDescriptorProto)] = true
+
+	for _, sym := range syms {
+		sym.GenerateAlias(g, g.packageOf(df.FileDescriptorProto))
+	}
+
+	g.P()
+}
+
+// Return a slice of all the types that are publicly imported into this file.
+func wrapImported(file *descriptor.FileDescriptorProto, g *Generator) (sl []*importDescriptor) {
+	for _, index := range file.PublicDependency {
+		df := g.fileByName(file.Dependency[index])
+		for _, d := range df.messages {
+			if d.GetOptions().GetMapEntry() {
+				continue
+			}
+			sl = append(sl, &importDescriptor{common{file}, d})
+		}
+		for _, e := range df.enums {
+			sl = append(sl, &importDescriptor{common{file}, e})
+		}
+		for _, ext := range df.extensions {
+			sl = append(sl, &importDescriptor{common{file}, ext})
+		}
+	}
+	return
+}
@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestImports(t *testing.T) {
+	kind := testFile("shared/kind.proto", "proto3", testMessage("Kind"))
+	kind.Package = proto.String("shared")
+	user := testFile("app/user.proto", "proto3", testMessage("User", testTypedField("kind", 1, typeMessage, ".shared.Kind")))
+	user.Dependency = []string{"shared/kind.proto"}
+
+	tests := []struct {
+		parameter string
+		want      []string
+	}{{
+		want: []string{
+			`import * as pb from "ts-protobuf";`,
+			`import * as shared from "../shared/kind.pb";`,
+			"pb.packageIsVersion1;",
+			"kind: shared.Kind | undefined = undefined;",
+			"encode(w: pb.Writer = new pb.Writer()): pb.Writer {",
+		},
+	}, {
+		parameter: "runtime=@acme/protobuf",
+		want:      []string{`import * as pb from "@acme/protobuf";`},
+	}, {
+		parameter: "import_prefix=@gen/",
+		want:      []string{`import * as shared from "@gen/shared/kind.pb";`},
+	}, {
+		parameter: "Mshared/kind.proto=@acme/kinds",
+		want:      []string{`import * as shared from "@acme/kinds";`},
+	}}
+	for _, tt := range tests {
+		out := generate(t, tt.parameter, kind, user)
+		wantContains(t, out, tt.want...)
+		wantLacks(t, out, "golang/protobuf", `"fmt"`, `"math"`)
+	}
+}
@@ -0,0 +1,276 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// jsonWriteValue returns the expression that converts the single value v of
+// the field to its proto3 JSON form.
+func (g *Generator) jsonWriteValue(field *descriptor.FieldDescriptorProto, v string) string {
+	runtime := g.Pkg["runtime"]
+	if name := wellKnown(field); name != "" {
+		return g.wellKnownCodec(name) + ".toJSON(" + g.wellKnownIn(name, v) + ")"
+	}
+	switch *field.Type {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE,
+		descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		return runtime + ".json.writeFloat(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SINT64:
+		return runtime + ".json.writeInt64(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return runtime + ".json.writeBytes(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		enum := g.TypeName(g.ObjectNamed(field.GetTypeName()))
+		return runtime + ".json.writeEnum(" + v + ", " + enum + "_name)"
+	case descriptor.FieldDescriptorProto_TYPE_GROUP,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		typ := g.TypeName(g.ObjectNamed(field.GetTypeName()))
+		if g.interfaces {
+			return typ + ".toJSON(" + v + ")"
+		}
+		return v + ".toJSON()"
+	}
+	return v
+}
+
+// jsonReadValue returns the expression that converts the single JSON value v
+// to a value of the field.
+func (g *Generator) jsonReadValue(field *descriptor.FieldDescriptorProto, v string) string {
+	runtime := g.Pkg["runtime"]
+	if name := wellKnown(field); name != "" {
+		return g.wellKnownOut(name, g.wellKnownCodec(name)+".fromJSON("+v+", options)")
+	}
+	switch *field.Type {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE,
+		descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		return runtime + ".json.float(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SINT64:
+		return g.longFromBigInt(runtime + ".json.int64(" + v + ")")
+	case descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		return g.longFromBigInt(runtime + ".json.uint64(" + v + ")")
+	case descriptor.FieldDescriptorProto_TYPE_INT32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptor.FieldDescriptorProto_TYPE_SINT32:
+		return runtime + ".json.int32(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_UINT32,
+		descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		return runtime + ".json.uint32(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return runtime + ".json.bool(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return runtime + ".json.string(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return runtime + ".json.bytes(" + v + ")"
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		enum := g.TypeName(g.ObjectNamed(field.GetTypeName()))
+		fullName := strings.TrimPrefix(field.GetTypeName(), ".")
+		return runtime + ".json.enumValue(" + v + ", " + enum + "_value, " + strconv.Quote(fullName) + ", options)"
+	case descriptor.FieldDescriptorProto_TYPE_GROUP,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		return g.TypeName(g.ObjectNamed(field.GetTypeName())) + ".fromJSON(" + v + ", options)"
+	}
+	return v
+}
+
+// jsonReadKey returns the expression that converts the JSON object key k to
+// a key of the map entry. Keys are strings in JSON whatever their type, so
+// they are checked and, in records, stored in canonical form.
+func (g *Generator) jsonReadKey(keyField *descriptor.FieldDescriptorProto, k string) string {
+	switch *keyField.Type {
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return k
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return g.Pkg["runtime"] + ".json.boolKey(" + k + ")"
+	}
+	// The readers of integers accept decimal strings.
+	return g.jsonReadValue(keyField, k)
+}
+
+// generateToJSON generates the method that returns the message in the proto3
+// JSON format. JSON.stringify calls it as well, but only in class mode.
+func (g *Generator) generateToJSON(message *messageDescriptor) {
+	fieldNames := message.fieldNames()
+	runtime := g.Pkg["runtime"]
+
+	g.P("/** Returns the message in the proto3 JSON format. */")
+	g.methodHeader(message, false, "toJSON", "", runtime+".JSONObject")
+	g.P("const o: ", runtime, ".JSONObject = {};")
+	for _, field := range message.Field {
+		if inOneof(field) {
+			if firstInOneof(message, field) {
+				oi := *field.OneofIndex
+				g.generateOneofSwitch(message, oi, "m."+message.oneofName(oi), func(field *descriptor.FieldDescriptorProto, v string) {
+					g.P("o[", strconv.Quote(jsonName(field)), "] = ", g.jsonWriteValue(field, v), ";")
+				})
+			}
+			continue
+		}
+		v := "m." + fieldNames[field]
+		key := "o[" + strconv.Quote(jsonName(field)) + "]"
+
+		switch {
+		case g.mapEntry(field) != nil:
+			entry := g.mapEntry(field)
+			g.P("if (", g.mapNotEmpty(v), ") {")
+			g.In()
+			g.P("const mo: ", runtime, ".JSONObject = {};")
+			g.P("for (const [k, v] of ", g.mapEntries(v), ") {")
+			g.In()
+			g.P("mo[String(k)] = ", g.jsonWriteValue(entry.Field[1], "v"), ";")
+			g.Out()
+			g.P("}")
+			g.P(key, " = mo;")
+			g.Out()
+			g.P("}")
+		case isRepeated(field):
+			g.P("if (", v, ".length > 0) {")
+			g.In()
+			g.P(key, " = ", v, ".map((v) => ", g.jsonWriteValue(field, "v"), ");")
+			g.Out()
+			g.P("}")
+		case g.hasPresence(message, field):
+			ref := g.presenceRef(message, field, "m")
+			g.P("if (", ref, " !== undefined) {")
+			g.In()
+			g.P(key, " = ", g.jsonWriteValue(field, ref), ";")
+			g.Out()
+			g.P("}")
+		case isMessage(field), message.proto3():
+			// Proto3 fields with their zero value are omitted.
+			g.P("if (!(", g.isZero(message, field, v), ")) {")
+			g.In()
+			g.P(key, " = ", g.jsonWriteValue(field, v), ";")
+			g.Out()
+			g.P("}")
+		default:
+			g.P(key, " = ", g.jsonWriteValue(field, v), ";")
+		}
+	}
+	g.P("return o;")
+	g.methodEnd()
+}
+
+// generateFromJSON generates the static method that reads the message from
+// the proto3 JSON format. Both the JSON name and the proto name of a field are
+// accepted, and null stands for the default value of any field but those
+// holding a google.protobuf.Value.
+func (g *Generator) generateFromJSON(message *messageDescriptor) {
+	className := message.GetName()
+	runtime := g.Pkg["runtime"]
+	fullName := strconv.Quote(message.fullName())
+
+	g.P("/**")
+	g.P(" * Reads the message from the proto3 JSON format. Unknown fields are")
+	g.P(" * rejected unless the options say to ignore them.")
+	g.P(" */")
+	g.methodHeader(message, true, "fromJSON", "json: "+runtime+".JSONValue, options?: "+runtime+".JSONReadOptions", className)
+	g.P("const o = ", runtime, ".json.object(json, ", fullName, ");")
+	g.P("const m = ", g.construct(className), ";")
+	g.P("for (const [k, v] of Object.entries(o)) {")
+	g.In()
+	// null is the value of a google.protobuf.Value rather than its absence.
+	null := "v === null"
+	for _, field := range message.Field {
+		if wellKnown(field) == "Value" && !isRepeated(field) {
+			null += " && k !== " + strconv.Quote(jsonName(field))
+			if field.GetName() != jsonName(field) {
+				null += " && k !== " + strconv.Quote(field.GetName())
+			}
+		}
+	}
+	g.P("if (", null, ") {")
+	g.In()
+	g.P("continue;")
+	g.Out()
+	g.P("}")
+	g.P("switch (k) {")
+	g.In()
+	for _, field := range message.Field {
+		p := g.assignRef(message, field, "m")
+		name := jsonName(field)
+		// The value read from an unknown enum name is undefined, so it is
+		// held in a constant, scoped to a block of its own, and set only if
+		// defined.
+		block := isEnum(field) && !isRepeated(field)
+		brace := ""
+		if block {
+			brace = " {"
+		}
+		if field.GetName() != name {
+			g.P("case ", strconv.Quote(name), ":")
+			g.P("case ", strconv.Quote(field.GetName()), ":", brace)
+		} else {
+			g.P("case ", strconv.Quote(name), ":", brace)
+		}
+		g.In()
+		switch {
+		case inOneof(field) && block:
+			g.generateEnumRead(field, "v", func(e string) string {
+				return oneofSet(field, "m."+message.oneofName(*field.OneofIndex), e) + ";"
+			})
+		case inOneof(field):
+			g.P(oneofSet(field, "m."+message.oneofName(*field.OneofIndex), g.jsonReadValue(field, "v")), ";")
+		case g.mapEntry(field) != nil:
+			entry := g.mapEntry(field)
+			g.P("for (const [mk, mv] of Object.entries(", runtime, ".json.object(v, ", fullName, "))) {")
+			g.In()
+			if isEnum(entry.Field[1]) {
+				g.generateEnumRead(entry.Field[1], "mv", func(e string) string {
+					return g.mapSet(entry, p, g.jsonReadKey(entry.Field[0], "mk"), e)
+				})
+			} else {
+				g.P(g.mapSet(entry, p, g.jsonReadKey(entry.Field[0], "mk"), g.jsonReadValue(entry.Field[1], "mv")))
+			}
+			g.Out()
+			g.P("}")
+		case isRepeated(field) && isEnum(field):
+			enum := g.TypeName(g.ObjectNamed(field.GetTypeName()))
+			g.P(p, " = ", runtime, ".json.array(v, ", fullName, ").map((v) => ", g.jsonReadValue(field, "v"), ").filter((e): e is ", enum, " => e !== undefined);")
+		case isRepeated(field):
+			g.P(p, " = ", runtime, ".json.array(v, ", fullName, ").map((v) => ", g.jsonReadValue(field, "v"), ");")
+		case block:
+			g.generateEnumRead(field, "v", func(e string) string {
+				return p + " = " + e + ";"
+			})
+		default:
+			g.P(p, " = ", g.jsonReadValue(field, "v"), ";")
+		}
+		g.P("break;")
+		g.Out()
+		if block {
+			g.P("}")
+		}
+	}
+	g.P("default:")
+	g.In()
+	g.P(runtime, ".json.unknownField(", fullName, ", k, options);")
+	g.Out()
+	g.Out()
+	g.P("}")
+	g.Out()
+	g.P("}")
+	g.P("return m;")
+	g.methodEnd()
+}
+
+// generateEnumRead generates the statements that read the enum value of the
+// field from the JSON value v and pass it to the statement returned by set,
+// unless the value has an unknown name that the options say to ignore.
+func (g *Generator) generateEnumRead(field *descriptor.FieldDescriptorProto, v string, set func(e string) string) {
+	g.P("const e = ", g.jsonReadValue(field, v), ";")
+	g.P("if (e !== undefined) {")
+	g.In()
+	g.P(set("e"))
+	g.Out()
+	g.P("}")
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestJSON(t *testing.T) {
+	sample := testMessage("Sample",
+		testField("user_name", 1, optional, typeString),
+		testField("offset", 2, optional, typeSint64),
+		testTypedField("color", 3, typeEnum, ".test.Color"),
+		testField("blob", 4, optional, typeBytes),
+		testField("ratio", 5, optional, typeDouble),
+	)
+	file := testFile("sample.proto", "proto3", sample)
+	file.EnumType = append(file.EnumType, testEnum("Color", "RED", 0, "GREEN", 1))
+
+	out := generate(t, "", file)
+	wantContains(t, out,
+		"toJSON(): pb.JSONObject {",
+		// lowerCamel names, and the special forms of the spec.
+		`o["userName"] = m.userName;`,
+		`o["offset"] = pb.json.writeInt64(m.offset);`,
+		`o["color"] = pb.json.writeEnum(m.color, Color_name);`,
+		`o["blob"] = pb.json.writeBytes(m.blob);`,
+		`o["ratio"] = pb.json.writeFloat(m.ratio);`,
+		"static fromJSON(json: pb.JSONValue, options?: pb.JSONReadOptions): Sample {",
+		// Parsers accept the proto field name as well.
+		"case \"userName\":\n\t\t\t\tcase \"user_name\":",
+		"m.offset = pb.json.int64(v);",
+		"case \"color\": {\n\t\t\t\t\tconst e = pb.json.enumValue(v, Color_value, \"test.Color\", options);\n\t\t\t\t\tif (e !== undefined) {\n\t\t\t\t\t\tm.color = e;",
+		"m.ratio = pb.json.float(v);",
+		`pb.json.unknownField("test.Sample", k, options);`,
+	)
+}
+
+func TestJSONUnknownEnumNames(t *testing.T) {
+	single := testTypedField("color", 1, typeEnum, ".test.Color")
+	list := testTypedField("colors", 2, typeEnum, ".test.Color")
+	list.Label = repeated.Enum()
+	choice := testTypedField("hue", 3, typeEnum, ".test.Color")
+	paint := testMessage("Paint", single, list, choice)
+	addOneof(paint, "kind", choice)
+	addMap(paint, "by_name", 4, typeString, typeEnum)
+	paint.NestedType[0].Field[1].TypeName = proto.String(".test.Color")
+	file := testFile("paint.proto", "proto3", paint)
+	file.EnumType = append(file.EnumType, testEnum("Color", "RED", 0, "GREEN", 1))
+
+	// An unknown name read with ignoreUnknownFields leaves the field unset.
+	out := generate(t, "", file)
+	wantContains(t, out,
+		"case \"color\": {\n\t\t\t\t\tconst e = pb.json.enumValue(v, Color_value, \"test.Color\", options);\n\t\t\t\t\tif (e !== undefined) {\n\t\t\t\t\t\tm.color = e;\n\t\t\t\t\t}\n\t\t\t\t\tbreak;\n\t\t\t\t}",
+		`m.colors = pb.json.array(v, "test.Paint").map((v) => pb.json.enumValue(v, Color_value, "test.Color", options)).filter((e): e is Color => e !== undefined);`,
+		"case \"hue\": {\n\t\t\t\t\tconst e = pb.json.enumValue(v, Color_value, \"test.Color\", options);\n\t\t\t\t\tif (e !== undefined) {\n\t\t\t\t\t\tm.kind = { case: \"hue\", value: e };",
+		"const e = pb.json.enumValue(mv, Color_value, \"test.Color\", options);\n\t\t\t\t\t\tif (e !== undefined) {\n\t\t\t\t\t\t\tm.byName.set(mk, e);",
+	)
+	wantLacks(t, out, `case "colors": {`, `case "byName": {`)
+}
+
+func TestJSONNameOption(t *testing.T) {
+	dashed := testField("foo_bar", 1, optional, typeString)
+	dashed.JsonName = proto.String("foo-bar")
+	renamed := testField("id", 2, optional, typeInt32)
+	renamed.JsonName = proto.String("ident")
+	sample := testMessage("Sample", dashed, renamed)
+	addOneof(sample, "key", renamed)
+
+	out := generate(t, "", testFile("sample.proto", "proto3", sample))
+	wantContains(t, out,
+		`fooBar: string = "";`,
+		`o["foo-bar"] = m.fooBar;`,
+		"case \"foo-bar\":\n\t\t\t\tcase \"foo_bar\":\n\t\t\t\t\tm.fooBar = pb.json.string(v);",
+		`| { case: "id"; value: number }`,
+		`o["ident"] = m.key.value;`,
+		"case \"ident\":\n\t\t\t\tcase \"id\":",
+	)
+	wantLacks(t, out, "m.foo-bar", `case: "ident"`)
+}
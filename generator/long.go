@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"strconv"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Representations of 64-bit integers, chosen with the long parameter. The
+// runtime Reader and Writer work with bigint, so the other representations
+// are converted at the boundary.
+const (
+	longBigInt = "bigint"
+	longString = "string" // Decimal strings, as in JSON.
+	longNumber = "number" // Lossy; values outside the safe integer range fail to decode.
+)
+
+// maxSafeInteger is Number.MAX_SAFE_INTEGER, the largest integer a number
+// holds exactly.
+const maxSafeInteger = 1<<53 - 1
+
+// is64 returns whether the field holds a 64-bit integer.
+func is64(field *descriptor.FieldDescriptorProto) bool {
+	switch field.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SINT64:
+		return true
+	}
+	return false
+}
+
+// isSafeInteger returns whether the decimal integer s is held exactly by a
+// number, as Number.isSafeInteger does.
+func isSafeInteger(s string) bool {
+	n, err := strconv.ParseInt(s, 10, 64)
+	return err == nil && n >= -maxSafeInteger && n <= maxSafeInteger
+}
+
+// longZero returns the zero value of a 64-bit integer.
+func (g *Generator) longZero() string {
+	switch g.long {
+	case longString:
+		return `"0"`
+	case longNumber:
+		return "0"
+	}
+	return "0n"
+}
+
+// longToBigInt returns the expression that converts the 64-bit integer v to
+// the bigint the Writer expects.
+func (g *Generator) longToBigInt(v string) string {
+	if g.long == longBigInt {
+		return v
+	}
+	return g.Pkg["runtime"] + ".Long.toBigInt(" + v + ")"
+}
+
+// longFromBigInt returns the expression that converts the bigint v, as
+// returned by the Reader, to the chosen representation.
+func (g *Generator) longFromBigInt(v string) string {
+	switch g.long {
+	case longString:
+		return v + ".toString()"
+	case longNumber:
+		// toNumber checks that no precision is lost.
+		return g.Pkg["runtime"] + ".Long.toNumber(" + v + ")"
+	}
+	return v
+}
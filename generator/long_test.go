@@ -0,0 +1,55 @@
+package generator
+
+import "testing"
+
+func TestLong(t *testing.T) {
+	big := testMessage("Big", testField("id", 1, optional, typeSint64), testField("ids", 2, repeated, typeSint64))
+	addMap(big, "by_id", 3, typeSint64, typeInt32)
+	file := testFile("big.proto", "proto3", big)
+
+	tests := []struct {
+		parameter string
+		want      []string
+	}{{
+		parameter: "",
+		want: []string{
+			"id: bigint = 0n;",
+			"byId: Map<bigint, number> = new Map();",
+			"w.tag(1, 0).sint64(m.id);",
+			"m.id = r.sint64();",
+			"m.id = pb.json.int64(v);",
+		},
+	}, {
+		parameter: "long=string",
+		want: []string{
+			`id: string = "0";`,
+			"ids: string[] = [];",
+			"byId: Map<string, number> = new Map();",
+			"w.tag(1, 0).sint64(pb.Long.toBigInt(m.id));",
+			"w.sint64(pb.Long.toBigInt(v));",
+			"w.tag(1, 0).sint64(pb.Long.toBigInt(k));",
+			"m.id = r.sint64().toString();",
+			"m.ids.push(r.sint64().toString());",
+			"key = r.sint64().toString();",
+			`o["id"] = pb.json.writeInt64(m.id);`,
+			"m.id = pb.json.int64(v).toString();",
+			"m.byId.set(pb.json.int64(mk).toString(), pb.json.int32(mv));",
+		},
+	}, {
+		parameter: "long=number",
+		want: []string{
+			"id: number = 0;",
+			"byId: Map<number, number> = new Map();",
+			"w.tag(1, 0).sint64(pb.Long.toBigInt(m.id));",
+			// The runtime checks that no precision is lost.
+			"m.id = pb.Long.toNumber(r.sint64());",
+			"key = pb.Long.toNumber(r.sint64());",
+			"m.id = pb.Long.toNumber(pb.json.int64(v));",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.parameter, func(t *testing.T) {
+			wantContains(t, generate(t, tt.parameter, file), tt.want...)
+		})
+	}
+}
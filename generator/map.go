@@ -0,0 +1,64 @@
+package generator
+
+import "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+// A map field is a Map keyed by the TypeScript type of its key field, or with
+// the records option a Record keyed by the key as a string, which is how JSON
+// writes it. The helpers below return the expressions that work with either.
+
+// mapType returns the TypeScript type of the map field with the given key and
+// value types.
+func (g *Generator) mapType(keyType, valType string) string {
+	if g.records {
+		return "Record<string, " + valType + ">"
+	}
+	return "Map<" + keyType + ", " + valType + ">"
+}
+
+// mapEntries returns the expression that iterates over the [key, value]
+// pairs of the map held in v.
+func (g *Generator) mapEntries(v string) string {
+	if g.records {
+		return "Object.entries(" + v + ")"
+	}
+	return v
+}
+
+// mapNotEmpty returns the condition under which the map held in v has entries.
+func (g *Generator) mapNotEmpty(v string) string {
+	if g.records {
+		return "Object.keys(" + v + ").length > 0"
+	}
+	return v + ".size > 0"
+}
+
+// mapSet returns the statement that sets the entry of the map held in v. The
+// key k has the type of the key field of the entry message.
+func (g *Generator) mapSet(entry *messageDescriptor, v, k, val string) string {
+	keyField := entry.Field[0]
+	if !g.records {
+		return v + ".set(" + k + ", " + val + ");"
+	}
+	if *keyField.Type != descriptor.FieldDescriptorProto_TYPE_STRING {
+		k = "String(" + k + ")"
+	}
+	return v + "[" + k + "] = " + val + ";"
+}
+
+// mapKey returns the expression that converts the key k of an entry, as
+// returned by mapEntries, to the type of the key field of the entry message.
+func (g *Generator) mapKey(entry *messageDescriptor, k string) string {
+	keyField := entry.Field[0]
+	if !g.records {
+		return k
+	}
+	switch typ, _ := g.TSType(entry, keyField); typ {
+	case "string":
+		return k
+	case "boolean":
+		return "(" + k + ` === "true")`
+	case "bigint":
+		return "BigInt(" + k + ")"
+	}
+	return "Number(" + k + ")"
+}
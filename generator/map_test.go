@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// addMap adds a map field with the key and value types to the message, with
+// its synthetic entry message.
+func addMap(message *descriptor.DescriptorProto, name string, number int32, key, value descriptor.FieldDescriptorProto_Type) {
+	entryName := CamelCase(name) + "Entry"
+	entry := testMessage(entryName, testField("key", 1, optional, key), testField("value", 2, optional, value))
+	entry.Options = &descriptor.MessageOptions{MapEntry: proto.Bool(true)}
+	message.NestedType = append(message.NestedType, entry)
+	field := testTypedField(name, number, typeMessage, ".test."+message.GetName()+"."+entryName)
+	field.Label = repeated.Enum()
+	message.Field = append(message.Field, field)
+}
+
+func TestMaps(t *testing.T) {
+	counts := testMessage("Counts")
+	addMap(counts, "by_id", 1, typeInt32, typeString)
+	addMap(counts, "flags", 2, typeBool, typeInt32)
+	file := testFile("counts.proto", "proto3", counts)
+
+	tests := []struct {
+		parameter string
+		want      []string
+	}{{
+		want: []string{
+			"byId: Map<number, string> = new Map();",
+			"flags: Map<boolean, number> = new Map();",
+			"for (const [k, v] of m.byId) {",
+			"w.tag(1, 0).int32(k);",
+			"m.byId.set(key, value);",
+			"mo[String(k)] = v;",
+			"m.byId.set(pb.json.int32(mk), pb.json.string(mv));",
+			"m.flags.set(pb.json.boolKey(mk), pb.json.int32(mv));",
+		},
+	}, {
+		parameter: "maps=record",
+		want: []string{
+			"byId: Record<string, string> = {};",
+			"flags: Record<string, number> = {};",
+			"for (const [k, v] of Object.entries(m.byId)) {",
+			// Keys are converted from their string form to be encoded.
+			"w.tag(1, 0).int32(Number(k));",
+			`w.tag(1, 0).bool((k === "true"));`,
+			"m.byId[String(key)] = value;",
+			"if (Object.keys(m.byId).length > 0) {",
+			"m.byId[String(pb.json.int32(mk))] = pb.json.string(mv);",
+		},
+	}}
+	for _, tt := range tests {
+		out := generate(t, tt.parameter, file)
+		wantContains(t, out, tt.want...)
+		// The entries are hidden.
+		wantLacks(t, out, "class ByIdEntry", "interface ByIdEntry", "ByIdEntry.decode")
+	}
+}
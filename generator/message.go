@@ -0,0 +1,593 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Method names that may be generated. Fields with these names get an
+// underscore appended. Any change to this set is a potential incompatible
+// API change because it changes generated field names.
+var methodNames = [...]string{
+	"clearExtension",
+	"constructor",
+	"encode",
+	"getExtension",
+	"hasExtension",
+	"setExtension",
+	"toJSON",
+	"validate",
+	"verify",
+}
+
+// Names declared by the namespace of a message in interface mode. Nested
+// extensions with these names get an underscore appended.
+var namespaceNames = [...]string{
+	"clearExtension",
+	"create",
+	"decode",
+	"encode",
+	"extendee",
+	"fromJSON",
+	"getExtension",
+	"hasExtension",
+	"setExtension",
+	"toJSON",
+	"validate",
+	"verify",
+}
+
+// Generate the class for this Descriptor, followed by the namespace holding
+// its nested types. In interface mode the message is an interface instead,
+// and its codec functions are part of the namespace.
+func (g *Generator) generateMessage(message *messageDescriptor) {
+	className := message.GetName()
+	fieldNames := message.fieldNames()
+
+	g.PrintComments(message.path, message.GetOptions().GetDeprecated())
+	if g.interfaces {
+		g.P("export interface ", className, " {")
+	} else {
+		g.P("export class ", className, " {")
+	}
+	g.In()
+
+	for i, field := range message.Field {
+		fieldName := fieldNames[field]
+		typename, _ := g.TSType(message, field)
+		// Well-known messages with native types don't use their file.
+		if wellKnown(field) == "" {
+			g.RecordTypeUse(field.GetTypeName())
+		}
+		if entry := g.mapEntry(field); entry != nil && wellKnown(entry.Field[1]) == "" {
+			// Message and enum types are the only two possibly foreign
+			// types used in maps, so record their use.
+			g.RecordTypeUse(entry.Field[1].GetTypeName())
+		}
+
+		if inOneof(field) {
+			if !firstInOneof(message, field) {
+				continue
+			}
+			oi := *field.OneofIndex
+			g.PrintComments(fmt.Sprintf("%s,%d,%d", message.path, messageOneofPath, oi), false)
+			g.P(message.oneofName(oi), ":")
+			if g.interfaces {
+				g.generateOneofType(message, oi, ";")
+			} else {
+				g.generateOneofType(message, oi, " = "+oneofUnset+";")
+			}
+			continue
+		}
+
+		if isMessage(field) && !isRepeated(field) {
+			typename += " | undefined"
+		}
+		path := fmt.Sprintf("%s,%d,%d", message.path, messageFieldPath, i)
+		if g.hasPresence(message, field) && !g.interfaces {
+			g.generatePresenceField(message, field, typename, path)
+			continue
+		}
+		if g.warnsDeprecated(field) && !g.interfaces {
+			g.generateDeprecatedField(message, field, typename, g.zeroValue(message, field), path)
+			continue
+		}
+		g.PrintComments(path, field.GetOptions().GetDeprecated())
+		if g.hasPresence(message, field) {
+			g.P(fieldName, "?: ", typename, ";")
+		} else if g.interfaces {
+			g.P(fieldName, ": ", typename, ";")
+		} else {
+			g.P(fieldName, ": ", typename, " = ", g.zeroValue(message, field), ";")
+		}
+	}
+
+	if isExtendable(message) {
+		g.P("/** The encoded extension fields, read and written by the extension accessors. */")
+		g.P("$extensions?: ", g.Pkg["runtime"], ".ExtensionFields;")
+	}
+	if g.unknown {
+		g.P("/** The encoded fields that were not recognized when decoding, written back by encoding. */")
+		g.P("$unknown?: Uint8Array[];")
+	}
+
+	if !g.interfaces {
+		g.P()
+		g.generateConstructor(message)
+		g.P()
+		g.generateMethods(message)
+	}
+	g.Out()
+	g.P("}")
+	g.P()
+
+	g.generateNested(message)
+
+	if message.parent == nil {
+		g.file.addExport(message, messageSymbol{className})
+	}
+
+	g.addInitf("%s.registry.register(%q, %s);", g.Pkg["runtime"], message.fullName(), g.TypeName(message))
+}
+
+// generateMethods generates the codec of the message, as methods of its class
+// or as functions of its namespace.
+func (g *Generator) generateMethods(message *messageDescriptor) {
+	g.generateEncode(message)
+	g.P()
+	g.generateDecode(message)
+	g.P()
+	g.generateToJSON(message)
+	g.P()
+	g.generateFromJSON(message)
+	g.P()
+	g.generateVerify(message)
+	g.P()
+	g.generateValidate(message)
+	if isExtendable(message) {
+		g.P()
+		g.generateExtensionAccessors(message)
+	}
+}
+
+// generateCreate generates the function that returns a new message in
+// interface mode, with every field set to its zero value unless init sets it.
+// Fields with presence are left unset.
+func (g *Generator) generateCreate(message *messageDescriptor) {
+	className := message.GetName()
+	fieldNames := message.fieldNames()
+
+	g.P("/** Returns a new ", className, " with the fields of init, and zero values for the others. */")
+	g.P("export function create(init?: Partial<", className, ">): ", className, " {")
+	g.In()
+	g.generateCreateWarnings(message)
+	g.P("return {")
+	g.In()
+	for _, field := range message.Field {
+		if inOneof(field) {
+			if firstInOneof(message, field) {
+				g.P(message.oneofName(*field.OneofIndex), ": ", oneofUnset, ",")
+			}
+			continue
+		}
+		if g.hasPresence(message, field) {
+			continue
+		}
+		g.P(fieldNames[field], ": ", g.zeroValue(message, field), ",")
+	}
+	g.P("...init,")
+	g.Out()
+	g.P("};")
+	g.Out()
+	g.P("}")
+}
+
+// openMethod is a codec method whose body is being generated, held back from
+// the output until methodEnd.
+type openMethod struct {
+	message        *messageDescriptor
+	static         bool
+	name           string
+	params, result string
+	out            *bytes.Buffer // The output before the method.
+}
+
+// methodHeader starts a codec method. In interface mode the method is a
+// function of the namespace of the message, and instance methods take the
+// message as their first parameter. Either way, the body refers to the
+// message as m. The body is generated next, and methodEnd prints the method
+// once it is known which parameters the body uses.
+func (g *Generator) methodHeader(message *messageDescriptor, static bool, name, params, result string) {
+	g.method = &openMethod{message, static, name, params, result, g.Buffer}
+	g.Buffer = new(bytes.Buffer)
+	g.In()
+}
+
+// identifierUse matches the uses of an identifier in generated code.
+func identifierUse(name string) *regexp.Regexp {
+	// A spread, but not a property access, may precede it.
+	return regexp.MustCompile(`(^|[^\w$.]|\.\.\.)` + regexp.QuoteMeta(name) + `\b`)
+}
+
+// methodEnd prints the method started by methodHeader, with its body. Unused
+// parameters get a leading underscore, and instance methods of classes only
+// declare m if they use it, so the code compiles with noUnusedLocals and
+// noUnusedParameters.
+func (g *Generator) methodEnd() {
+	method := g.method
+	body := g.String()
+	g.Buffer, g.method = method.out, nil
+	g.Out()
+
+	uses := func(name string) bool {
+		return identifierUse(name).MatchString(body)
+	}
+	var params []string
+	if method.params != "" {
+		for _, p := range strings.Split(method.params, ", ") {
+			if name := p[:strings.IndexAny(p, "?:")]; !uses(name) {
+				p = "_" + p
+			}
+			params = append(params, p)
+		}
+	}
+	if g.interfaces && !method.static {
+		m := "m: " + method.message.GetName()
+		if !uses("m") {
+			m = "_" + m
+		}
+		params = append([]string{m}, params...)
+	}
+	list := strings.Join(params, ", ")
+	switch {
+	case g.interfaces:
+		g.P("export function ", method.name, "(", list, "): ", method.result, " {")
+	case method.static:
+		g.P("static ", method.name, "(", list, "): ", method.result, " {")
+	default:
+		g.P(method.name, "(", list, "): ", method.result, " {")
+	}
+	if !g.interfaces && !method.static && uses("m") {
+		g.In()
+		g.P("const m = this;")
+		g.Out()
+	}
+	g.Write([]byte(body))
+	g.P("}")
+}
+
+// construct returns the expression that creates an empty message of the
+// printed type typ.
+func (g *Generator) construct(typ string) string {
+	if g.interfaces {
+		return typ + ".create()"
+	}
+	return "new " + typ + "()"
+}
+
+// encodeCall returns the expression that writes the message v of the printed
+// type typ to the writer w, or to a new writer if w is empty.
+func (g *Generator) encodeCall(typ, v, w string) string {
+	if g.interfaces {
+		if w != "" {
+			w = ", " + w
+		}
+		return typ + ".encode(" + v + w + ")"
+	}
+	return v + ".encode(" + w + ")"
+}
+
+// generateNested emits the types declared within a message as a namespace
+// merged with its class, so that they are addressed as Outer.Inner.
+func (g *Generator) generateNested(message *messageDescriptor) {
+	var nested []*messageDescriptor
+	for _, desc := range message.nested {
+		// Don't generate virtual messages for maps.
+		if desc.GetOptions().GetMapEntry() {
+			continue
+		}
+		nested = append(nested, desc)
+	}
+	types := len(nested) > 0 || len(message.enums) > 0 || len(message.extensions) > 0
+	if !g.interfaces && !types {
+		return
+	}
+
+	g.P("export namespace ", message.GetName(), " {")
+	g.In()
+	if g.interfaces {
+		g.generateDefaultConstants(message)
+		g.generateCreate(message)
+		g.P()
+		g.generateMethods(message)
+		if types {
+			g.P()
+		}
+	}
+	for _, enum := range message.enums {
+		g.generateEnum(enum)
+	}
+	for _, desc := range nested {
+		g.generateMessage(desc)
+	}
+	for _, ext := range message.extensions {
+		g.generateExtension(ext)
+	}
+	g.Out()
+	g.P("}")
+	g.P()
+}
+
+// zeroValue returns the TypeScript expression a field is initialized to.
+func (g *Generator) zeroValue(message *messageDescriptor, field *descriptor.FieldDescriptorProto) string {
+	if g.mapEntry(field) != nil {
+		if g.records {
+			return "{}"
+		}
+		return "new Map()"
+	}
+	if isRepeated(field) {
+		return "[]"
+	}
+	switch *field.Type {
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return "false"
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return `""`
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return "new Uint8Array(0)"
+	case descriptor.FieldDescriptorProto_TYPE_GROUP,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		return "undefined"
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		// The default default for an enum is the first value in the enum,
+		// not zero.
+		obj := g.ObjectNamed(field.GetTypeName())
+		enum := enumOf(obj)
+		if enum == nil {
+			g.warnf("don't know how to generate default for %s", field.GetName())
+			return "0"
+		}
+		if len(enum.Value) == 0 {
+			return "0" // empty enum
+		}
+		return g.TypeName(obj) + "." + enum.Value[0].GetName()
+	}
+	if is64(field) {
+		return g.longZero()
+	}
+	return "0"
+}
+
+// mapEntry returns the synthetic entry message of a map field, or nil if the
+// field is not a map.
+func (g *Generator) mapEntry(field *descriptor.FieldDescriptorProto) *messageDescriptor {
+	if *field.Type != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+		return nil
+	}
+	// Map entries are always declared in the same file as their field, so
+	// they are never publicly imported.
+	if d, ok := g.ObjectNamed(field.GetTypeName()).(*messageDescriptor); ok && d.GetOptions().GetMapEntry() {
+		return d
+	}
+	return nil
+}
+
+// Scan the messages in this file.  For each one, build the slice of nested descriptors
+func (g *Generator) buildNestedMessages(descs []*messageDescriptor) {
+	for _, desc := range descs {
+		if len(desc.NestedType) != 0 {
+			for _, nest := range descs {
+				if nest.parent == desc {
+					desc.nested = append(desc.nested, nest)
+				}
+			}
+			if len(desc.nested) != len(desc.NestedType) {
+				g.Fail("internal error: nesting failure for", desc.GetName())
+			}
+		}
+	}
+}
+
+type messageSymbol struct {
+	sym string
+}
+
+// GenerateAlias re-exports the class, together with the namespace of its
+// nested types.
+func (ms messageSymbol) GenerateAlias(g *Generator, pkg string) {
+	g.P("export import ", ms.sym, " = ", pkg, ".", ms.sym, ";")
+}
+
+// Descriptor represents a protocol buffer message.
+type messageDescriptor struct {
+	common
+	*descriptor.DescriptorProto
+	parent     *messageDescriptor     // The containing message, if any.
+	nested     []*messageDescriptor   // Inner messages, if any.
+	enums      []*enumDescriptor      // Inner enums, if any.
+	extensions []*extensionDescriptor // Extensions, if any.
+	typename   []string               // Cached typename vector.
+	index      int                    // The index into the container, whether the file or another message.
+	path       string                 // The SourceCodeInfo path as comma-separated integers.
+	group      bool
+
+	accessors bool // Fields with presence get hasX and clearX methods.
+	functions bool // The namespace holds the codec functions, as in interface mode.
+
+	names         map[*descriptor.FieldDescriptorProto]string    // Cached property names.
+	oneofNames    map[int32]string                               // Cached property names of oneofs.
+	accessorNames map[*descriptor.FieldDescriptorProto][2]string // Cached names of the has and clear methods.
+	extNames      map[*extensionDescriptor]string                // Cached const names of extensions.
+}
+
+func newMessage(desc *descriptor.DescriptorProto, parent *messageDescriptor, file *descriptor.FileDescriptorProto, index int) *messageDescriptor {
+	d := &messageDescriptor{
+		common:          common{file},
+		DescriptorProto: desc,
+		parent:          parent,
+		index:           index,
+	}
+	if parent == nil {
+		d.path = fmt.Sprintf("%d,%d", messagePath, index)
+	} else {
+		d.path = fmt.Sprintf("%s,%d,%d", parent.path, messageMessagePath, index)
+	}
+
+	// The only way to distinguish a group from a message is whether
+	// the containing message has a TYPE_GROUP field that matches.
+	if parent != nil {
+		parts := d.TypeName()
+		if file.Package != nil {
+			parts = append([]string{*file.Package}, parts...)
+		}
+		exp := "." + strings.Join(parts, ".")
+		for _, field := range parent.Field {
+			if field.GetType() == descriptor.FieldDescriptorProto_TYPE_GROUP && field.GetTypeName() == exp {
+				d.group = true
+				break
+			}
+		}
+	}
+
+	for i, field := range desc.Extension {
+		path := fmt.Sprintf("%s,%d,%d", d.path, messageExtensionPath, i)
+		d.extensions = append(d.extensions, &extensionDescriptor{common{file}, field, d, path})
+	}
+
+	return d
+}
+
+// Return a slice of all the Messages defined within this file
+func wrapMessages(file *descriptor.FileDescriptorProto) []*messageDescriptor {
+	sl := make([]*messageDescriptor, 0, len(file.MessageType)+10)
+	for i, desc := range file.MessageType {
+		sl = wrapThisDescriptor(sl, desc, nil, file, i)
+	}
+	return sl
+}
+
+// Wrap this Descriptor, recursively
+func wrapThisDescriptor(sl []*messageDescriptor, desc *descriptor.DescriptorProto, parent *messageDescriptor, file *descriptor.FileDescriptorProto, index int) []*messageDescriptor {
+	sl = append(sl, newMessage(desc, parent, file, index))
+	me := sl[len(sl)-1]
+	for i, nested := range desc.NestedType {
+		sl = wrapThisDescriptor(sl, nested, me, file, i)
+	}
+	return sl
+}
+
+// fieldNames returns the property name of each field. The fields of a oneof
+// share the single property returned by oneofName instead. The names are
+// allocated once so that the class and all of its generated methods agree on
+// them, together with the names of the has and clear methods of fields with
+// presence, which must not collide with them either.
+func (d *messageDescriptor) fieldNames() map[*descriptor.FieldDescriptorProto]string {
+	if d.names != nil {
+		return d.names
+	}
+	usedNames := make(map[string]bool)
+	for _, n := range methodNames {
+		usedNames[n] = true
+	}
+	allocate := func(n string) string {
+		for usedNames[n] {
+			n += "_"
+		}
+		usedNames[n] = true
+		return n
+	}
+	d.names = make(map[*descriptor.FieldDescriptorProto]string)
+	d.oneofNames = make(map[int32]string)
+	d.accessorNames = make(map[*descriptor.FieldDescriptorProto][2]string)
+	for _, field := range d.Field {
+		// TODO: This allocation occurs based on the order of the fields
+		// in the proto file, meaning that a change in the field
+		// ordering can change generated property names.
+		if inOneof(field) {
+			oi := *field.OneofIndex
+			if _, ok := d.oneofNames[oi]; !ok {
+				d.oneofNames[oi] = allocate(lowerCamelCase(d.OneofDecl[oi].GetName()))
+			}
+			continue
+		}
+		name := allocate(lowerCamelCase(field.GetName()))
+		d.names[field] = name
+		if d.accessors && d.hasPresence(field) {
+			d.accessorNames[field] = [2]string{allocate("has" + upperFirst(name)), allocate("clear" + upperFirst(name))}
+		}
+	}
+	return d.names
+}
+
+// accessorName returns the names of the has and clear methods of the field
+// with presence.
+func (d *messageDescriptor) accessorName(field *descriptor.FieldDescriptorProto) (has, clear string) {
+	d.fieldNames()
+	names := d.accessorNames[field]
+	return names[0], names[1]
+}
+
+// oneofName returns the name of the property holding the oneof.
+func (d *messageDescriptor) oneofName(oi int32) string {
+	d.fieldNames()
+	return d.oneofNames[oi]
+}
+
+// extensionName returns the name of the constant holding the descriptor of
+// the nested extension. When the namespace also holds the codec functions,
+// the names are allocated in declaration order around theirs.
+func (d *messageDescriptor) extensionName(ext *extensionDescriptor) string {
+	if d.extNames != nil {
+		return d.extNames[ext]
+	}
+	usedNames := make(map[string]bool)
+	if d.functions {
+		for _, n := range namespaceNames {
+			usedNames[n] = true
+		}
+	}
+	d.extNames = make(map[*extensionDescriptor]string)
+	for _, e := range d.extensions {
+		n := lowerCamelCase(e.GetName())
+		for usedNames[n] {
+			n += "_"
+		}
+		usedNames[n] = true
+		d.extNames[e] = n
+	}
+	return d.extNames[ext]
+}
+
+// fullName returns the fully-qualified proto name of the message, such as
+// "my.test.Reply.Entry".
+func (d *messageDescriptor) fullName() string {
+	name := dottedSlice(d.TypeName())
+	if pkg := d.File().GetPackage(); pkg != "" {
+		name = pkg + "." + name
+	}
+	return name
+}
+
+// TypeName returns the elements of the dotted type name. The package name is
+// not part of this name.
+func (d *messageDescriptor) TypeName() []string {
+	if d.typename != nil {
+		return d.typename
+	}
+	n := 0
+	for parent := d; parent != nil; parent = parent.parent {
+		n++
+	}
+	s := make([]string, n, n)
+	for parent := d; parent != nil; parent = parent.parent {
+		n--
+		s[n] = parent.GetName()
+	}
+	d.typename = s
+	return s
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestMessageClass(t *testing.T) {
+	inner := testMessage("Inner", testField("ok", 1, optional, typeBool))
+	outer := testMessage("Outer",
+		testField("user_name", 1, optional, typeString),
+		testField("tags", 2, repeated, typeString),
+		testTypedField("inner", 3, typeMessage, ".test.Outer.Inner"),
+	)
+	outer.NestedType = []*descriptor.DescriptorProto{inner}
+	out := generate(t, "", testFile("outer.proto", "proto3", outer))
+	wantContains(t, out,
+		"export class Outer {",
+		`userName: string = "";`,
+		"tags: string[] = [];",
+		"inner: Outer.Inner | undefined = undefined;",
+		"constructor(init?: Partial<Outer>) {",
+		"Object.assign(this, init);",
+		"export namespace Outer {",
+		"export class Inner {",
+		"ok: boolean = false;",
+	)
+	wantLacks(t, out, "Outer struct", "Reset()", "XXX_")
+}
+
+func TestInterfaceMode(t *testing.T) {
+	point := testMessage("Point", testField("x", 1, optional, typeInt32), testTypedField("next", 2, typeMessage, ".test.Point"))
+	out := generate(t, "mode=interfaces", testFile("point.proto", "proto3", point))
+	wantContains(t, out,
+		"export interface Point {",
+		"x: number;",
+		"next: Point | undefined;",
+		"export namespace Point {",
+		"export function create(init?: Partial<Point>): Point {",
+		"...init,",
+		"export function encode(m: Point, w: pb.Writer = new pb.Writer()): pb.Writer {",
+		"Point.encode(m.next, w.tag(2, 2).fork()).ldelim();",
+		"export function decode(input: pb.Reader | Uint8Array, length?: number): Point {",
+		"const m = Point.create();",
+		"export function toJSON(m: Point): pb.JSONObject {",
+		`o["next"] = Point.toJSON(m.next);`,
+		"export function fromJSON(json: pb.JSONValue, options?: pb.JSONReadOptions): Point {",
+	)
+	wantLacks(t, out, "export class", "this.")
+
+	out = generate(t, "mode=interfaces", testServiceFile())
+	wantContains(t, out, "(m: Request): Uint8Array => Request.encode(m).finish(),")
+}
+
+func TestMethodsDeclareOnlyWhatTheyUse(t *testing.T) {
+	empty := testFile("empty.proto", "proto3", testMessage("Empty"))
+
+	out := generate(t, "unknown_fields=false", empty)
+	wantLacks(t, out, "const m = this;")
+	wantContains(t, out, `verify(_path: string = "Empty"): string[]`, `validate(_path: string = "Empty")`)
+
+	out = generate(t, "unknown_fields=false,mode=interfaces", empty)
+	wantContains(t, out, `export function verify(_m: Empty, _path: string = "Empty"): string[]`)
+
+	id := testField("id", 1, optional, typeInt32)
+	out = generate(t, "", testFile("one.proto", "proto3", testMessage("One", id)))
+	if n := strings.Count(out, "const m = this;"); n == 0 {
+		t.Errorf("no method of a message with a field declares m:\n%s", out)
+	}
+	wantContains(t, out, "toJSON(): ")
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// A oneof is a single property holding a discriminated union: the case is the
+// name of the field that is set, and the value is the value of that field.
+// When no field is set the case is undefined.
+
+// inOneof returns whether the field belongs to a oneof declared in the proto
+// file. The synthetic oneof protoc declares for each proto3 optional field
+// does not count: such fields are generated like other fields with presence.
+func inOneof(field *descriptor.FieldDescriptorProto) bool {
+	return field.OneofIndex != nil && !field.GetProto3Optional()
+}
+
+// oneofUnset is the value of a oneof property with no field set.
+const oneofUnset = "{ case: undefined }"
+
+// oneofFields returns the fields of the message that belong to the oneof.
+func oneofFields(message *messageDescriptor, oi int32) []*descriptor.FieldDescriptorProto {
+	var fields []*descriptor.FieldDescriptorProto
+	for _, field := range message.Field {
+		if inOneof(field) && *field.OneofIndex == oi {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// firstInOneof returns whether the field is the first of its oneof. The whole
+// oneof is generated in its place.
+func firstInOneof(message *messageDescriptor, field *descriptor.FieldDescriptorProto) bool {
+	return inOneof(field) && oneofFields(message, *field.OneofIndex)[0] == field
+}
+
+// oneofCase returns the case of the union that holds the field, its
+// lowerCamelCase name.
+func oneofCase(field *descriptor.FieldDescriptorProto) string {
+	return strconv.Quote(lowerCamelCase(field.GetName()))
+}
+
+// generateOneofType prints the members of the union type of the oneof, one
+// per line, each preceded by "| " and the comments of its field. The suffix
+// ends the last line.
+func (g *Generator) generateOneofType(message *messageDescriptor, oi int32, suffix string) {
+	g.In()
+	for i, field := range message.Field {
+		if !inOneof(field) || *field.OneofIndex != oi {
+			continue
+		}
+		typename, _ := g.TSType(message, field)
+		g.PrintComments(fmt.Sprintf("%s,%d,%d", message.path, messageFieldPath, i), field.GetOptions().GetDeprecated())
+		g.P("| { case: ", oneofCase(field), "; value: ", typename, " }")
+	}
+	g.P("| ", oneofUnset, suffix)
+	g.Out()
+}
+
+// oneofSet returns the expression that sets the oneof held in p to the field
+// with value v.
+func oneofSet(field *descriptor.FieldDescriptorProto, p, v string) string {
+	return p + " = { case: " + oneofCase(field) + ", value: " + v + " }"
+}
+
+// generateOneofSwitch generates a switch on the case of the oneof held in p.
+// The body of each case is generated by gen, given the field and its value.
+func (g *Generator) generateOneofSwitch(message *messageDescriptor, oi int32, p string, gen func(field *descriptor.FieldDescriptorProto, v string)) {
+	g.P("switch (", p, ".case) {")
+	g.In()
+	for _, field := range oneofFields(message, oi) {
+		g.P("case ", oneofCase(field), ":")
+		g.In()
+		gen(field, p+".value")
+		g.P("break;")
+		g.Out()
+	}
+	g.Out()
+	g.P("}")
+}
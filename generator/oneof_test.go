@@ -0,0 +1,38 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// addOneof declares the oneof in the message and puts the fields in it.
+func addOneof(message *descriptor.DescriptorProto, name string, fields ...*descriptor.FieldDescriptorProto) {
+	index := int32(len(message.OneofDecl))
+	message.OneofDecl = append(message.OneofDecl, &descriptor.OneofDescriptorProto{Name: proto.String(name)})
+	for _, f := range fields {
+		f.OneofIndex = proto.Int32(index)
+	}
+}
+
+func TestOneofUnion(t *testing.T) {
+	name := testField("name", 1, optional, typeString)
+	id := testField("id", 2, optional, typeInt32)
+	lookup := testMessage("Lookup", name, id, testField("limit", 3, optional, typeInt32))
+	addOneof(lookup, "key", name, id)
+
+	out := generate(t, "", testFile("lookup.proto", "proto3", lookup))
+	wantContains(t, out,
+		"key:\n\t\t| { case: \"name\"; value: string }\n\t\t| { case: \"id\"; value: number }\n\t\t| { case: undefined } = { case: undefined };",
+		"switch (m.key.case) {",
+		"w.tag(1, 2).string(m.key.value);",
+		// The last member on the wire wins.
+		`m.key = { case: "name", value: r.string() };`,
+		`m.key = { case: "id", value: r.int32() };`,
+		`o["id"] = m.key.value;`,
+		`m.key = { case: "id", value: pb.json.int32(v) };`,
+		"m.limit = r.int32();",
+	)
+	wantLacks(t, out, "name: string", "isLookup_", "oneof key")
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	isTypeScriptKeyword = map[string]bool{
+		"as":         true,
+		"break":      true,
+		"case":       true,
+		"catch":      true,
+		"class":      true,
+		"const":      true,
+		"continue":   true,
+		"debugger":   true,
+		"default":    true,
+		"delete":     true,
+		"do":         true,
+		"else":       true,
+		"enum":       true,
+		"export":     true,
+		"extends":    true,
+		"false":      true,
+		"finally":    true,
+		"for":        true,
+		"function":   true,
+		"if":         true,
+		"implements": true,
+		"import":     true,
+		"in":         true,
+		"instanceof": true,
+		"interface":  true,
+		"let":        true,
+		"new":        true,
+		"null":       true,
+		"package":    true,
+		"private":    true,
+		"protected":  true,
+		"public":     true,
+		"return":     true,
+		"static":     true,
+		"super":      true,
+		"switch":     true,
+		"this":       true,
+		"throw":      true,
+		"true":       true,
+		"try":        true,
+		"typeof":     true,
+		"var":        true,
+		"void":       true,
+		"while":      true,
+		"with":       true,
+		"yield":      true,
+	}
+)
+
+// Create and remember a guaranteed unique package name for this file descriptor.
+// Pkg is the candidate name.  If f is nil, it's a builtin package like "proto" and
+// has no file descriptor.
+func (g *Generator) RegisterUniquePackageName(pkg string, f *fileDescriptor) string {
+	// Convert dots to underscores before finding a unique alias.
+	pkg = strings.Map(badToUnderscore, pkg)
+	// Identifier must not be keyword: insert _.
+	if isTypeScriptKeyword[pkg] {
+		pkg = "_" + pkg
+	}
+	// Identifier must not begin with digit: insert _.
+	if r, _ := utf8.DecodeRuneInString(pkg); unicode.IsDigit(r) {
+		pkg = "_" + pkg
+	}
+
+	for i, orig := 1, pkg; g.pkgNamesInUse[pkg]; i++ {
+		// It's a duplicate; must rename.
+		pkg = orig + strconv.Itoa(i)
+	}
+	// Install it.
+	g.pkgNamesInUse[pkg] = true
+	if f != nil {
+		g.packageNames[f.FileDescriptorProto] = pkg
+	}
+	return pkg
+}
+
+// DefaultPackageName returns the import alias printed for the object. If it
+// is defined in another file, it returns the alias we're using for that file,
+// plus ".". Otherwise it returns the empty string.
+func (g *Generator) DefaultPackageName(obj ProtoObject) string {
+	if obj.File() == g.file.FileDescriptorProto {
+		return ""
+	}
+	return g.packageOf(obj.File()) + "."
+}
+
+// SetPackageNames defines the import alias of every file in the request.
+// Every generated file is a module of its own, so even files that share a
+// proto package are imported under distinct names.
+func (g *Generator) SetPackageNames() {
+	// Register the runtime name first. It might collide with the name of a
+	// package we import.
+	g.Pkg = map[string]string{
+		"runtime": g.RegisterUniquePackageName("pb", nil),
+	}
+
+	for _, f := range g.allFiles {
+		pkg := f.GetPackage()
+		if pkg == "" {
+			pkg = baseName(*f.Name)
+		}
+		g.RegisterUniquePackageName(pkg, f)
+	}
+}
@@ -0,0 +1,96 @@
+package generator
+
+import "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+// Ways to track the presence of fields, chosen with the presence parameter.
+// Interfaces always use optional properties.
+const (
+	// A private property that is undefined while the field is unset backs
+	// accessors that return the default, and hasX and clearX methods.
+	presenceAccessors = "accessors"
+	// The field is an optional property, undefined while unset.
+	presenceOptional = "optional"
+)
+
+// hasPresence returns whether the field tracks whether it is set, apart from
+// its value. These are the singular fields of proto2 and the fields declared
+// optional in proto3. Messages and oneofs have presence of their own, being
+// undefined while unset, and repeated fields have none.
+func (g *Generator) hasPresence(message *messageDescriptor, field *descriptor.FieldDescriptorProto) bool {
+	return message.hasPresence(field)
+}
+
+// hasPresence is the part of Generator.hasPresence that depends on the message
+// alone, for use when naming its members.
+func (d *messageDescriptor) hasPresence(field *descriptor.FieldDescriptorProto) bool {
+	if isRepeated(field) || isMessage(field) || inOneof(field) {
+		return false
+	}
+	return !d.proto3() || field.GetProto3Optional()
+}
+
+// optionalProperties returns whether fields with presence are generated as
+// optional properties rather than accessors.
+func (g *Generator) optionalProperties() bool {
+	return g.interfaces || g.presence == presenceOptional
+}
+
+// presenceRef returns the expression holding the value of the field with
+// presence in the message m, which is undefined while the field is unset.
+func (g *Generator) presenceRef(message *messageDescriptor, field *descriptor.FieldDescriptorProto, m string) string {
+	if g.optionalProperties() {
+		return m + "." + message.fieldNames()[field]
+	}
+	return m + "._" + message.fieldNames()[field]
+}
+
+// generatePresenceField generates the class members of a field with presence:
+// its static default, if any, and either an optional property or a private
+// property with accessors.
+func (g *Generator) generatePresenceField(message *messageDescriptor, field *descriptor.FieldDescriptorProto, typename, path string) {
+	name := message.fieldNames()[field]
+	fallback := g.zeroValue(message, field)
+	if def := g.defaultValue(message, field); def != "" {
+		g.P("static readonly ", defaultName(field), ": ", typename, " = ", def, ";")
+		fallback = message.GetName() + "." + defaultName(field)
+	}
+	if g.optionalProperties() && g.warnsDeprecated(field) {
+		g.generateDeprecatedField(message, field, typename+" | undefined", "undefined", path)
+		return
+	}
+	if g.optionalProperties() {
+		g.PrintComments(path, field.GetOptions().GetDeprecated())
+		g.P(name, "?: ", typename, ";")
+		return
+	}
+
+	ref := g.presenceRef(message, field, "this")
+	has, clear := message.accessorName(field)
+	g.P("private _", name, "?: ", typename, ";")
+	g.PrintComments(path, field.GetOptions().GetDeprecated())
+	g.P("get ", name, "(): ", typename, " {")
+	g.In()
+	g.P("return ", ref, " ?? ", fallback, ";")
+	g.Out()
+	g.P("}")
+	g.P("set ", name, "(v: ", typename, ") {")
+	g.In()
+	if g.warnsDeprecated(field) {
+		g.P(g.deprecationWarning(message, field))
+	}
+	g.P(ref, " = v;")
+	g.Out()
+	g.P("}")
+	g.P("/** Returns whether ", name, " is set, even to its default. */")
+	g.P(has, "(): boolean {")
+	g.In()
+	g.P("return ", ref, " !== undefined;")
+	g.Out()
+	g.P("}")
+	g.P("/** Unsets ", name, ", which then reads as its default. */")
+	g.P(clear, "(): void {")
+	g.In()
+	g.P(ref, " = undefined;")
+	g.Out()
+	g.P("}")
+}
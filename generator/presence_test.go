@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+)
+
+func TestPresence(t *testing.T) {
+	flags := testMessage("Flags",
+		testField("count", 1, optional, typeInt32),
+		withDefault(testField("limit", 2, optional, typeInt32), "10"),
+		testField("ids", 3, repeated, typeInt32),
+	)
+	file := testFile("flags.proto", "proto2", flags)
+
+	out := generate(t, "", file)
+	wantContains(t, out,
+		"private _count?: number;",
+		"get count(): number {",
+		"return this._count ?? 0;",
+		"hasCount(): boolean {",
+		"return this._count !== undefined;",
+		"clearCount(): void {",
+		"this._count = undefined;",
+		"return this._limit ?? Flags.DEFAULT_LIMIT;",
+		// Set fields are written even when they hold the zero value.
+		"if (m._count !== undefined) {",
+		"w.tag(1, 0).int32(m._count);",
+		`o["count"] = m._count;`,
+		"ids: number[] = [];",
+	)
+	wantLacks(t, out, "hasIds")
+
+	out = generate(t, "presence=optional", file)
+	wantContains(t, out,
+		"count?: number;",
+		"static readonly DEFAULT_LIMIT: number = 10;",
+		"limit?: number;",
+		"if (m.count !== undefined) {",
+	)
+	wantLacks(t, out, "hasCount", "private _count")
+
+	// Proto3 fields have no presence.
+	out = generate(t, "", testFile("flags.proto", "proto3", testMessage("Flags", testField("count", 1, optional, typeInt32))))
+	wantContains(t, out, "count: number = 0;")
+	wantLacks(t, out, "hasCount")
+}
+
+func TestProto3Optional(t *testing.T) {
+	count := testField("count", 1, optional, typeInt32)
+	count.Proto3Optional = proto.Bool(true)
+	name := testField("name", 2, optional, typeString)
+	id := testField("id", 3, optional, typeInt32)
+	lookup := testMessage("Lookup", count, name, id)
+	// protoc declares a synthetic oneof for each optional field, after the
+	// real ones.
+	addOneof(lookup, "key", name, id)
+	addOneof(lookup, "_count", count)
+	file := testFile("lookup.proto", "proto3", lookup)
+
+	out := generate(t, "", file)
+	wantContains(t, out,
+		"private _count?: number;",
+		"hasCount(): boolean {",
+		"if (m._count !== undefined) {",
+		`| { case: "name"; value: string }`,
+	)
+	wantLacks(t, out, "_count:", `case: "count"`)
+
+	resp, err := Generate(request("", file), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetSupportedFeatures()&uint64(plugin.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL) == 0 {
+		t.Errorf("supported features = %d, want FEATURE_PROTO3_OPTIONAL", resp.GetSupportedFeatures())
+	}
+}
+
+func TestPresenceAccessorNames(t *testing.T) {
+	message := testMessage("Flags",
+		testField("key_that_needs_1234camel_CasIng", 1, optional, typeInt32),
+		testField("foo", 2, optional, typeInt32),
+		testField("has_foo", 3, optional, typeInt32),
+	)
+	out := generate(t, "", testFile("flags.proto", "proto2", message))
+	wantContains(t, out,
+		"hasKeyThatNeeds1234camelCasIng(): boolean {",
+		"clearKeyThatNeeds1234camelCasIng(): void {",
+		"hasFoo(): boolean {",
+		"get hasFoo_(): number {",
+		"hasHasFoo_(): boolean {",
+	)
+	wantLacks(t, out, "hasKeyThatNeeds1234CamelCasIng", "get hasFoo(): number")
+}
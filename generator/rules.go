@@ -0,0 +1,277 @@
+package generator
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Constraints are declared on fields with the option of protoc-gen-validate,
+// (validate.rules), an extension of google.protobuf.FieldOptions holding a
+// validate.FieldRules message. It is read from the encoded options, so the
+// generator does not depend on the Go package of validate.proto.
+
+// Field number of the validate.rules extension.
+const validateRulesNumber = 1071
+
+// Kinds of validate.FieldRules, by field number.
+var ruleKinds = map[uint64]string{
+	1:  "float",
+	2:  "double",
+	3:  "int32",
+	4:  "int64",
+	5:  "uint32",
+	6:  "uint64",
+	7:  "sint32",
+	8:  "sint64",
+	9:  "fixed32",
+	10: "fixed64",
+	11: "sfixed32",
+	12: "sfixed64",
+	13: "bool",
+	14: "string",
+	15: "bytes",
+	16: "enum",
+	17: "message",
+	18: "repeated",
+	19: "map",
+}
+
+// Names of the supported rules of each kind, by field number. Other rules are
+// ignored.
+var (
+	numberRuleNames = map[uint64]string{1: "const", 2: "lt", 3: "lte", 4: "gt", 5: "gte", 6: "in", 7: "not_in"}
+	ruleNames       = map[string]map[uint64]string{
+		"bool":     {1: "const"},
+		"string":   {1: "const", 19: "len", 2: "min_len", 3: "max_len", 6: "pattern", 7: "prefix", 8: "suffix", 9: "contains", 23: "not_contains", 10: "in", 11: "not_in"},
+		"bytes":    {13: "len", 2: "min_len", 3: "max_len"},
+		"enum":     {1: "const", 2: "defined_only", 3: "in", 4: "not_in"},
+		"message":  {1: "skip", 2: "required"},
+		"repeated": {1: "min_items", 2: "max_items", 3: "unique"},
+		"map":      {1: "min_pairs", 2: "max_pairs"},
+	}
+)
+
+// fieldRules are the constraints declared on a field.
+type fieldRules struct {
+	kind    string              // The kind of the rules, such as "int32" or "repeated".
+	message messageRules        // The message rules, which may accompany any kind.
+	rules   map[string][]string // TypeScript literals of the arguments of each rule.
+	items   *fieldRules         // The rules of the elements of a repeated field.
+}
+
+// messageRules are the rules of a message field.
+type messageRules struct {
+	skip     bool // Don't validate the message.
+	required bool // The message must be set.
+}
+
+// wireField is a field of an encoded message.
+type wireField struct {
+	number   uint64
+	wireType int
+	varint   uint64 // The value of varint, fixed32 and fixed64 fields.
+	bytes    []byte // The value of length-delimited fields.
+}
+
+var errBadWire = errors.New("malformed protocol buffer")
+
+// parseWire splits the encoding of a message into its fields.
+func parseWire(b []byte) ([]wireField, error) {
+	var fields []wireField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errBadWire
+		}
+		b = b[n:]
+		f := wireField{number: tag >> 3, wireType: int(tag & 7)}
+		switch f.wireType {
+		case 0:
+			f.varint, n = binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errBadWire
+			}
+			b = b[n:]
+		case 1:
+			if len(b) < 8 {
+				return nil, errBadWire
+			}
+			f.varint, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, errBadWire
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		case 5:
+			if len(b) < 4 {
+				return nil, errBadWire
+			}
+			f.varint, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			// Groups don't occur in validate.proto.
+			return nil, errBadWire
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// rulesOf returns the constraints declared on the field, or nil if there are
+// none.
+func (g *Generator) rulesOf(field *descriptor.FieldDescriptorProto) *fieldRules {
+	if field.Options == nil {
+		return nil
+	}
+	// The options are encoded by the library that declares their type, which
+	// writes back the extensions it does not know.
+	b, err := proto.Marshal(field.Options)
+	if err != nil {
+		g.ErrorAt(g.fieldPath(field), err, "encoding options of", field.GetName())
+	}
+	fields, err := parseWire(b)
+	if err != nil {
+		g.ErrorAt(g.fieldPath(field), err, "reading options of", field.GetName())
+	}
+	for _, f := range fields {
+		if f.number == validateRulesNumber && f.wireType == 2 {
+			rules, err := parseFieldRules(f.bytes)
+			if err != nil {
+				g.ErrorAt(g.fieldPath(field), err, "reading validate.rules of", field.GetName())
+			}
+			return rules
+		}
+	}
+	return nil
+}
+
+// parseFieldRules decodes a validate.FieldRules message.
+func parseFieldRules(b []byte) (*fieldRules, error) {
+	fields, err := parseWire(b)
+	if err != nil {
+		return nil, err
+	}
+	r := &fieldRules{rules: make(map[string][]string)}
+	for _, f := range fields {
+		kind, ok := ruleKinds[f.number]
+		if !ok || f.wireType != 2 {
+			continue
+		}
+		kindFields, err := parseWire(f.bytes)
+		if err != nil {
+			return nil, err
+		}
+		if kind == "message" {
+			for _, kf := range kindFields {
+				switch ruleNames[kind][kf.number] {
+				case "skip":
+					r.message.skip = kf.varint != 0
+				case "required":
+					r.message.required = kf.varint != 0
+				}
+			}
+			continue
+		}
+		r.kind = kind
+		names := ruleNames[kind]
+		if names == nil {
+			names = numberRuleNames
+		}
+		for _, kf := range kindFields {
+			if kind == "repeated" && kf.number == 4 && kf.wireType == 2 {
+				if r.items, err = parseFieldRules(kf.bytes); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			name, ok := names[kf.number]
+			if !ok {
+				continue
+			}
+			r.rules[name] = append(r.rules[name], ruleLiterals(kind, name, kf)...)
+		}
+	}
+	return r, nil
+}
+
+// ruleLiterals returns the TypeScript literals of the argument of a rule.
+// Repeated numbers may be packed, so there may be several.
+func ruleLiterals(kind, name string, f wireField) []string {
+	if f.wireType == 2 {
+		switch kind {
+		case "string":
+			return []string{jsQuote(string(f.bytes))}
+		case "bytes":
+			return nil
+		}
+		// A packed list of numbers.
+		var lits []string
+		b := f.bytes
+		for len(b) > 0 {
+			var v uint64
+			switch kind {
+			case "float", "fixed32", "sfixed32":
+				if len(b) < 4 {
+					return lits
+				}
+				v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+			case "double", "fixed64", "sfixed64":
+				if len(b) < 8 {
+					return lits
+				}
+				v, b = binary.LittleEndian.Uint64(b), b[8:]
+			default:
+				var n int
+				if v, n = binary.Uvarint(b); n <= 0 {
+					return lits
+				}
+				b = b[n:]
+			}
+			lits = append(lits, numberLiteral(kind, v))
+		}
+		return lits
+	}
+	switch kind {
+	case "bool", "string", "bytes", "repeated", "map":
+		// Booleans, lengths and counts.
+		if name == "const" || name == "unique" {
+			return []string{strconv.FormatBool(f.varint != 0)}
+		}
+		return []string{strconv.FormatUint(f.varint, 10)}
+	case "enum":
+		if name == "defined_only" {
+			return []string{strconv.FormatBool(f.varint != 0)}
+		}
+		return []string{strconv.Itoa(int(int32(f.varint)))}
+	}
+	return []string{numberLiteral(kind, f.varint)}
+}
+
+// numberLiteral returns the TypeScript literal of a number of the given kind,
+// as encoded in v. 64-bit integers are bigint literals.
+func numberLiteral(kind string, v uint64) string {
+	switch kind {
+	case "float":
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(v))), 'g', -1, 32)
+	case "double":
+		return strconv.FormatFloat(math.Float64frombits(v), 'g', -1, 64)
+	case "int32", "sfixed32":
+		return strconv.Itoa(int(int32(v)))
+	case "uint32", "fixed32":
+		return strconv.FormatUint(uint64(uint32(v)), 10)
+	case "sint32":
+		return strconv.Itoa(int(int32(uint32(v)>>1) ^ -int32(v&1)))
+	case "int64", "sfixed64":
+		return strconv.FormatInt(int64(v), 10) + "n"
+	case "uint64", "fixed64":
+		return strconv.FormatUint(v, 10) + "n"
+	case "sint64":
+		return strconv.FormatInt(int64(v>>1)^-int64(v&1), 10) + "n"
+	}
+	return strconv.FormatUint(v, 10)
+}
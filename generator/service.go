@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Generate the client interface for this service, the descriptors of its
+// methods and a client that sends calls through a runtime Transport.
+func (g *Generator) generateService(service *descriptor.ServiceDescriptorProto, index int) {
+	name := service.GetName()
+	path := fmt.Sprintf("%d,%d", servicePath, index)
+	fullName := name
+	if g.file.Package != nil {
+		fullName = *g.file.Package + "." + fullName
+	}
+	methods := g.rpcNames(service)
+
+	g.PrintComments(path, service.GetOptions().GetDeprecated())
+	g.P("export interface ", name, "Client {")
+	g.In()
+	for i, method := range service.Method {
+		g.PrintComments(fmt.Sprintf("%s,%d,%d", path, serviceMethodPath, i), method.GetOptions().GetDeprecated())
+		g.P(methods[method], g.methodSignature(method), ";")
+	}
+	g.Out()
+	g.P("}")
+	g.P()
+
+	g.P("/** Descriptors of the methods of the ", name, " service. */")
+	g.P("export const ", name, "Methods = {")
+	g.In()
+	for i, method := range service.Method {
+		in, out := g.methodTypes(method)
+		g.P(methods[method], ": new ", g.Pkg["runtime"], ".Method<", in, ", ", out, ">(")
+		g.In()
+		g.P(strconv.Quote(fullName), ",")
+		g.P(strconv.Quote(method.GetName()), ",")
+		g.P(i+1, ",")
+		g.P(method.GetClientStreaming(), ",")
+		g.P(method.GetServerStreaming(), ",")
+		g.P("(m: ", in, "): Uint8Array => ", g.encodeCall(in, "m", ""), ".finish(),")
+		g.P("(b: Uint8Array): ", out, " => ", out, ".decode(b)")
+		g.Out()
+		g.P("),")
+	}
+	g.Out()
+	g.P("};")
+	g.P()
+
+	g.P("/** Client of the ", name, " service that sends calls through a transport. */")
+	g.P("export class ", name, "ClientImpl implements ", name, "Client {")
+	g.In()
+	g.P("constructor(private readonly transport: ", g.Pkg["runtime"], ".Transport) {}")
+	for _, method := range service.Method {
+		mname := methods[method]
+		arg := "request"
+		call := "unary"
+		switch {
+		case method.GetClientStreaming() && method.GetServerStreaming():
+			arg, call = "requests", "bidiStream"
+		case method.GetClientStreaming():
+			arg, call = "requests", "clientStream"
+		case method.GetServerStreaming():
+			call = "serverStream"
+		}
+		g.P()
+		g.P(mname, g.methodSignature(method), " {")
+		g.In()
+		g.P("return this.transport.", call, "(", name, "Methods.", mname, ", ", arg, ");")
+		g.Out()
+		g.P("}")
+	}
+	g.Out()
+	g.P("}")
+	g.P()
+
+	if g.wsrpc {
+		g.generateWSClient(service)
+	}
+}
+
+// rpcNames returns the name of the generated client method for each RPC.
+func (g *Generator) rpcNames(service *descriptor.ServiceDescriptorProto) map[*descriptor.MethodDescriptorProto]string {
+	names := make(map[*descriptor.MethodDescriptorProto]string)
+	usedNames := map[string]bool{"constructor": true, "transport": true}
+	for _, method := range service.Method {
+		n := lowerFirst(method.GetName())
+		for usedNames[n] {
+			n += "_"
+		}
+		usedNames[n] = true
+		names[method] = n
+	}
+	return names
+}
+
+// methodTypes returns the printed request and response types of the method,
+// recording their use.
+func (g *Generator) methodTypes(method *descriptor.MethodDescriptorProto) (in, out string) {
+	g.RecordTypeUse(method.GetInputType())
+	g.RecordTypeUse(method.GetOutputType())
+	in = g.TypeName(g.ObjectNamed(method.GetInputType()))
+	out = g.TypeName(g.ObjectNamed(method.GetOutputType()))
+	return
+}
+
+// methodSignature returns the parameter list and return type of the client
+// method. Unary results are promises and streams are async iterables.
+func (g *Generator) methodSignature(method *descriptor.MethodDescriptorProto) string {
+	in, out := g.methodTypes(method)
+	param := "request: " + in
+	if method.GetClientStreaming() {
+		param = "requests: AsyncIterable<" + in + ">"
+	}
+	result := "Promise<" + out + ">"
+	if method.GetServerStreaming() {
+		result = "AsyncIterable<" + out + ">"
+	}
+	return "(" + param + "): " + result
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// testMethod returns an RPC taking and returning the messages with the full
+// names, streaming them on the client or server side as asked.
+func testMethod(name, in, out string, clientStreaming, serverStreaming bool) *descriptor.MethodDescriptorProto {
+	return &descriptor.MethodDescriptorProto{
+		Name:            proto.String(name),
+		InputType:       proto.String(in),
+		OutputType:      proto.String(out),
+		ClientStreaming: proto.Bool(clientStreaming),
+		ServerStreaming: proto.Bool(serverStreaming),
+	}
+}
+
+// testServiceFile returns a file declaring the messages Request and Response,
+// and the service Test with an RPC of every kind, as testdata/grpc.proto does.
+func testServiceFile() *descriptor.FileDescriptorProto {
+	file := testFile("grpc.proto", "proto3", testMessage("Request"), testMessage("Response"))
+	file.Service = []*descriptor.ServiceDescriptorProto{{
+		Name: proto.String("Test"),
+		Method: []*descriptor.MethodDescriptorProto{
+			testMethod("UnaryCall", ".test.Request", ".test.Response", false, false),
+			testMethod("Downstream", ".test.Request", ".test.Response", false, true),
+			testMethod("Upstream", ".test.Request", ".test.Response", true, false),
+			testMethod("Bidi", ".test.Request", ".test.Response", true, true),
+			// Would shadow the transport of the client.
+			testMethod("Transport", ".test.Request", ".test.Response", false, false),
+		},
+	}}
+	return file
+}
+
+func TestServiceClient(t *testing.T) {
+	out := generate(t, "", testServiceFile())
+	wantContains(t, out,
+		"export interface TestClient {",
+		"unaryCall(request: Request): Promise<Response>;",
+		"downstream(request: Request): AsyncIterable<Response>;",
+		"upstream(requests: AsyncIterable<Request>): Promise<Response>;",
+		"bidi(requests: AsyncIterable<Request>): AsyncIterable<Response>;",
+		"transport_(request: Request): Promise<Response>;",
+		"export const TestMethods = {",
+		"unaryCall: new pb.Method<Request, Response>(",
+		`"test.Test",`,
+		"export class TestClientImpl implements TestClient {",
+		"constructor(private readonly transport: pb.Transport) {}",
+		"return this.transport.unary(TestMethods.unaryCall, request);",
+		"return this.transport.serverStream(TestMethods.downstream, request);",
+		"return this.transport.clientStream(TestMethods.upstream, requests);",
+		"return this.transport.bidiStream(TestMethods.bidi, requests);",
+	)
+}
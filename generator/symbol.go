@@ -1,4 +1,4 @@
-package main
+package generator
 
 // symbol is an interface representing an exported Go symbol.
 type symbol interface {
@@ -1,34 +1,12 @@
-package main
+package generator
 
 import "github.com/golang/protobuf/protoc-gen-go/descriptor"
 
-// Names of messages in the `google.protobuf` package for which
-// we will generate XXX_WellKnownType methods.
-var wellKnownTypes = map[string]bool{
-	"Any":       true,
-	"Duration":  true,
-	"Empty":     true,
-	"Struct":    true,
-	"Timestamp": true,
-
-	"Value":       true,
-	"ListValue":   true,
-	"DoubleValue": true,
-	"FloatValue":  true,
-	"Int64Value":  true,
-	"UInt64Value": true,
-	"Int32Value":  true,
-	"UInt32Value": true,
-	"BoolValue":   true,
-	"StringValue": true,
-	"BytesValue":  true,
-}
-
 // BuildTypeNameMap builds the map from fully qualified type names to objects.
 // The key names for the map come from the input data, which puts a period at the beginning.
 // It should be called after SetPackageNames and before GenerateAllFiles.
 func (g *Generator) BuildTypeNameMap() {
-	g.typeNameToObject = make(map[string]Object)
+	g.typeNameToObject = make(map[string]ProtoObject)
 	for _, f := range g.allFiles {
 		// The names in this loop are defined by the proto world, not us, so the
 		// package name may be empty.  If so, the dotted package name of X will
@@ -37,11 +15,11 @@ func (g *Generator) BuildTypeNameMap() {
 		if dottedPkg != "." {
 			dottedPkg += "."
 		}
-		for _, enum := range f.enum {
+		for _, enum := range f.enums {
 			name := dottedPkg + dottedSlice(enum.TypeName())
 			g.typeNameToObject[name] = enum
 		}
-		for _, desc := range f.desc {
+		for _, desc := range f.messages {
 			name := dottedPkg + dottedSlice(desc.TypeName())
 			g.typeNameToObject[name] = desc
 		}
@@ -49,74 +27,77 @@ func (g *Generator) BuildTypeNameMap() {
 }
 
 // TypeName is the printed name appropriate for an item. If the object is in the current file,
-// TypeName drops the package name and underscores the rest.
-// Otherwise the object is from another package; and the result is the underscored
-// package name followed by the item name.
-// The result always has an initial capital.
+// TypeName is the dotted name of the item, nested types being reached through
+// the namespace of their parent. Otherwise the object is from another file and
+// the result is the import alias of that file followed by the item name.
 func (g *Generator) TypeName(obj ProtoObject) string {
-	return g.DefaultPackageName(obj) + CamelCaseSlice(obj.TypeName())
+	return g.DefaultPackageName(obj) + dottedSlice(obj.TypeName())
 }
 
-// TypeNameWithPackage is like TypeName, but always includes the package
-// name even if the object is in our own package.
+// TypeNameWithPackage is like TypeName, but always includes the import
+// alias even if the object is in the current file.
 func (g *Generator) TypeNameWithPackage(obj ProtoObject) string {
-	return obj.PackageName() + CamelCaseSlice(obj.TypeName())
+	return g.packageOf(obj.File()) + "." + dottedSlice(obj.TypeName())
 }
 
-// GoType returns a string representing the type name, and the wire type
-func (g *Generator) GoType(message *messageDescriptor, field *descriptor.FieldDescriptorProto) (typ string, wire string) {
+// TSType returns a string representing the TypeScript type of the field,
+// and the wire type. 64-bit integers have the type chosen with the long
+// parameter, and some well-known messages have native types.
+func (g *Generator) TSType(message *messageDescriptor, field *descriptor.FieldDescriptorProto) (typ string, wire string) {
 	// TODO: Options.
 	switch *field.Type {
 	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
-		typ, wire = "float64", "fixed64"
+		typ, wire = "number", "fixed64"
 	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
-		typ, wire = "float32", "fixed32"
+		typ, wire = "number", "fixed32"
 	case descriptor.FieldDescriptorProto_TYPE_INT64:
-		typ, wire = "int64", "varint"
+		typ, wire = g.long, "varint"
 	case descriptor.FieldDescriptorProto_TYPE_UINT64:
-		typ, wire = "uint64", "varint"
+		typ, wire = g.long, "varint"
 	case descriptor.FieldDescriptorProto_TYPE_INT32:
-		typ, wire = "int32", "varint"
+		typ, wire = "number", "varint"
 	case descriptor.FieldDescriptorProto_TYPE_UINT32:
-		typ, wire = "uint32", "varint"
+		typ, wire = "number", "varint"
 	case descriptor.FieldDescriptorProto_TYPE_FIXED64:
-		typ, wire = "uint64", "fixed64"
+		typ, wire = g.long, "fixed64"
 	case descriptor.FieldDescriptorProto_TYPE_FIXED32:
-		typ, wire = "uint32", "fixed32"
+		typ, wire = "number", "fixed32"
 	case descriptor.FieldDescriptorProto_TYPE_BOOL:
-		typ, wire = "bool", "varint"
+		typ, wire = "boolean", "varint"
 	case descriptor.FieldDescriptorProto_TYPE_STRING:
 		typ, wire = "string", "bytes"
 	case descriptor.FieldDescriptorProto_TYPE_GROUP:
 		desc := g.ObjectNamed(field.GetTypeName())
-		typ, wire = "*"+g.TypeName(desc), "group"
+		typ, wire = g.TypeName(desc), "group"
 	case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		if name := wellKnown(field); name != "" {
+			typ, wire = g.wellKnownType(name), "bytes"
+			break
+		}
 		desc := g.ObjectNamed(field.GetTypeName())
-		typ, wire = "*"+g.TypeName(desc), "bytes"
+		typ, wire = g.TypeName(desc), "bytes"
 	case descriptor.FieldDescriptorProto_TYPE_BYTES:
-		typ, wire = "[]byte", "bytes"
+		typ, wire = "Uint8Array", "bytes"
 	case descriptor.FieldDescriptorProto_TYPE_ENUM:
 		desc := g.ObjectNamed(field.GetTypeName())
 		typ, wire = g.TypeName(desc), "varint"
 	case descriptor.FieldDescriptorProto_TYPE_SFIXED32:
-		typ, wire = "int32", "fixed32"
+		typ, wire = "number", "fixed32"
 	case descriptor.FieldDescriptorProto_TYPE_SFIXED64:
-		typ, wire = "int64", "fixed64"
+		typ, wire = g.long, "fixed64"
 	case descriptor.FieldDescriptorProto_TYPE_SINT32:
-		typ, wire = "int32", "zigzag32"
+		typ, wire = "number", "zigzag32"
 	case descriptor.FieldDescriptorProto_TYPE_SINT64:
-		typ, wire = "int64", "zigzag64"
+		typ, wire = g.long, "zigzag64"
 	default:
-		g.Fail("unknown type for", field.GetName())
+		g.FailAt(g.fieldPath(field), "unknown type for", field.GetName())
 	}
-	if isRepeated(field) {
-		typ = "[]" + typ
-	} else if message != nil && message.proto3() {
-		return
-	} else if field.OneofIndex != nil && message != nil {
-		return
-	} else if needsStar(*field.Type) {
-		typ = "*" + typ
+	if entry := g.mapEntry(field); entry != nil {
+		keyType, _ := g.TSType(entry, entry.Field[0])
+		valType, _ := g.TSType(entry, entry.Field[1])
+		typ = g.mapType(keyType, valType)
+	} else if isRepeated(field) {
+		typ += "[]"
 	}
 	return
 }
@@ -125,7 +106,7 @@ func (g *Generator) RecordTypeUse(t string) {
 	if obj, ok := g.typeNameToObject[t]; ok {
 		// Call ObjectNamed to get the true object to record the use.
 		obj = g.ObjectNamed(t)
-		g.usedPackages[obj.PackageName()] = true
+		g.usedPackages[g.packageOf(obj.File())] = true
 	}
 }
 
@@ -138,24 +119,35 @@ func (g *Generator) WrapTypes() {
 	for _, f := range g.Request.ProtoFile {
 		// We must wrap the descriptors before we wrap the enums
 		descs := wrapMessages(f)
-		g.buildNestedDescriptors(descs)
-		enums := wrapEnumDescriptors(f, descs)
+		g.buildNestedMessages(descs)
+		enums := wrapEnums(f, descs)
 		g.buildNestedEnums(descs, enums)
 		exts := wrapExtensions(f)
 		fd := &fileDescriptor{
 			FileDescriptorProto: f,
-			desc:                descs,
-			enum:                enums,
-			ext:                 exts,
-			exported:            make(map[Object][]symbol),
+			messages:            descs,
+			enums:               enums,
+			extensions:          exts,
+			exports:             make(map[ProtoObject][]symbol),
 			proto3:              fileIsProto3(f),
 		}
-		extractComments(fd)
+		extractLocations(fd)
+		for _, desc := range descs {
+			desc.accessors = !g.optionalProperties()
+			desc.functions = g.interfaces
+		}
 		g.allFiles = append(g.allFiles, fd)
 		g.allFilesByName[f.GetName()] = fd
 	}
 	for _, fd := range g.allFiles {
-		fd.imp = wrapImported(fd.FileDescriptorProto, g)
+		for _, dep := range fd.Dependency {
+			if g.allFilesByName[dep] == nil {
+				g.Fail("missing dependency", dep, "of", fd.GetName())
+			}
+		}
+	}
+	for _, fd := range g.allFiles {
+		fd.imports = wrapImported(fd.FileDescriptorProto, g)
 	}
 
 	g.genFiles = make([]*fileDescriptor, 0, len(g.Request.FileToGenerate))
@@ -1,4 +1,4 @@
-package main
+package generator
 
 import (
 	"strconv"
@@ -34,6 +34,21 @@ func isRepeated(field *descriptor.FieldDescriptorProto) bool {
 	return field.Label != nil && *field.Label == descriptor.FieldDescriptorProto_LABEL_REPEATED
 }
 
+// Is this field a message or a group?
+func isMessage(field *descriptor.FieldDescriptorProto) bool {
+	switch field.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE,
+		descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return true
+	}
+	return false
+}
+
+// Is this field an enum?
+func isEnum(field *descriptor.FieldDescriptorProto) bool {
+	return field.GetType() == descriptor.FieldDescriptorProto_TYPE_ENUM
+}
+
 // Is this field a scalar numeric type?
 func isScalar(field *descriptor.FieldDescriptorProto) bool {
 	if field.Type == nil {
@@ -140,6 +155,55 @@ func CamelCase(s string) string {
 	return string(t)
 }
 
+// jsonName returns the name of the field in the proto3 JSON format: its
+// json_name option, or else its lowerCamelCase name. The generated property
+// is named after the field itself, as json_name need not be an identifier.
+func jsonName(field *descriptor.FieldDescriptorProto) string {
+	if name := field.GetJsonName(); name != "" {
+		return name
+	}
+	return lowerCamelCase(field.GetName())
+}
+
+// lowerCamelCase converts a field name the way protoc derives json_name:
+// underscores are dropped and the letter following one is upper-cased.
+// In short, _my_field_name_2 becomes MyFieldName2.
+func lowerCamelCase(s string) string {
+	t := make([]byte, 0, len(s))
+	upper := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_':
+			upper = true
+			continue
+		case upper && isASCIILower(c):
+			c ^= ' ' // Make it a capital letter.
+		}
+		upper = false
+		t = append(t, c)
+	}
+	return string(t)
+}
+
+// lowerFirst returns the name with its first letter in lower case, turning
+// an RPC name such as UnaryCall into the method name unaryCall.
+func lowerFirst(s string) string {
+	if s == "" || !unicode.IsUpper(rune(s[0])) {
+		return s
+	}
+	return string(unicode.ToLower(rune(s[0]))) + s[1:]
+}
+
+// upperFirst returns s with its first letter in upper case, leaving the rest
+// as it is.
+func upperFirst(s string) string {
+	if s == "" || !unicode.IsLower(rune(s[0])) {
+		return s
+	}
+	return string(unicode.ToUpper(rune(s[0]))) + s[1:]
+}
+
 // unescape reverses the "C" escaping that protoc does for default values of bytes fields.
 // It is best effort in that it effectively ignores malformed input. Seemingly invalid escape
 // sequences are conveyed, unmodified, into the decoded result.
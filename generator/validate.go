@@ -0,0 +1,364 @@
+package generator
+
+import (
+	"encoding/json"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// check is a condition a valid value satisfies, and the rule it enforces.
+type check struct {
+	cond    string // TypeScript condition that holds for valid values.
+	rule    string // The rule, such as "int32.gte".
+	message string // Description of the constraint.
+}
+
+// comparisons of numbers, by rule name.
+var comparisons = []struct{ rule, op, message string }{
+	{"const", "===", "must equal"},
+	{"lt", "<", "must be less than"},
+	{"lte", "<=", "must be less than or equal to"},
+	{"gt", ">", "must be greater than"},
+	{"gte", ">=", "must be greater than or equal to"},
+}
+
+// comparisonOps are the operators of the bounds of ranges, by rule name.
+var comparisonOps = map[string]string{"lt": "<", "lte": "<=", "gt": ">", "gte": ">="}
+
+// bounds returns the names of the lower and upper bound rules of the number
+// rules, either of which may be empty.
+func bounds(r *fieldRules) (lower, upper string) {
+	for _, name := range []string{"gt", "gte"} {
+		if len(r.rules[name]) > 0 {
+			lower = name
+		}
+	}
+	for _, name := range []string{"lt", "lte"} {
+		if len(r.rules[name]) > 0 {
+			upper = name
+		}
+	}
+	return
+}
+
+// rangeCheck returns the check of the value v against both a lower and an
+// upper bound, as protoc-gen-validate specifies it: the value must lie
+// between the bounds, or outside of them if the upper bound is below the
+// lower one.
+func rangeCheck(r *fieldRules, lower, upper, v string) check {
+	lo, hi := r.rules[lower][0], r.rules[upper][0]
+	cond := v + " " + comparisonOps[lower] + " " + lo
+	upperCond := v + " " + comparisonOps[upper] + " " + hi
+	brackets := map[string]string{"gt": "(", "gte": "[", "lt": ")", "lte": "]"}
+	if compareLiterals(hi, lo) > 0 {
+		return check{
+			cond:    cond + " && " + upperCond,
+			rule:    r.kind + "." + lower + "_" + upper,
+			message: "must be inside range " + brackets[lower] + displayLiteral(lo) + ", " + displayLiteral(hi) + brackets[upper],
+		}
+	}
+	// The valid values are outside of [hi, lo], whose ends are included
+	// unless the bounds include them.
+	outside := map[string]string{"lt": "[", "lte": "(", "gt": "]", "gte": ")"}
+	return check{
+		cond:    cond + " || " + upperCond,
+		rule:    r.kind + "." + lower + "_" + upper + "_exclusive",
+		message: "must be outside range " + outside[upper] + displayLiteral(hi) + ", " + displayLiteral(lo) + outside[lower],
+	}
+}
+
+// compareLiterals compares the values of two number literals, returning -1, 0
+// or +1.
+func compareLiterals(a, b string) int {
+	x, _ := new(big.Float).SetString(strings.TrimSuffix(a, "n"))
+	y, _ := new(big.Float).SetString(strings.TrimSuffix(b, "n"))
+	if x == nil || y == nil {
+		return 0
+	}
+	return x.Cmp(y)
+}
+
+// displayLiteral returns the literal as it reads in a violation message: a
+// bigint literal without its suffix.
+func displayLiteral(lit string) string {
+	if strings.HasSuffix(lit, "n") && !strings.HasPrefix(lit, "\"") {
+		return strings.TrimSuffix(lit, "n")
+	}
+	return lit
+}
+
+// displayLiterals applies displayLiteral to each literal.
+func displayLiterals(lits []string) []string {
+	var display []string
+	for _, lit := range lits {
+		display = append(display, displayLiteral(lit))
+	}
+	return display
+}
+
+// ruleChecks returns the checks of the value v of the field, or of its elements
+// if the rules are the items of a repeated field.
+func (g *Generator) ruleChecks(field *descriptor.FieldDescriptorProto, r *fieldRules, v string) []check {
+	var checks []check
+	add := func(name, cond, message string) {
+		checks = append(checks, check{cond, r.kind + "." + name, message})
+	}
+	inChecks := func(v string) {
+		if in := r.rules["in"]; len(in) > 0 {
+			add("in", "["+strings.Join(in, ", ")+"].includes("+v+")", "must be one of "+strings.Join(displayLiterals(in), ", "))
+		}
+		if notIn := r.rules["not_in"]; len(notIn) > 0 {
+			add("not_in", "!["+strings.Join(notIn, ", ")+"].includes("+v+")", "must not be one of "+strings.Join(displayLiterals(notIn), ", "))
+		}
+	}
+	lengths := func(length, unit string) {
+		if n := r.rules["len"]; len(n) > 0 {
+			add("len", length+" === "+n[0], "must be "+n[0]+" "+unit+" long")
+		}
+		if n := r.rules["min_len"]; len(n) > 0 {
+			add("min_len", length+" >= "+n[0], "must be at least "+n[0]+" "+unit+" long")
+		}
+		if n := r.rules["max_len"]; len(n) > 0 {
+			add("max_len", length+" <= "+n[0], "must be at most "+n[0]+" "+unit+" long")
+		}
+	}
+
+	switch r.kind {
+	case "bool":
+		if c := r.rules["const"]; len(c) > 0 {
+			add("const", v+" === "+c[0], "must equal "+c[0])
+		}
+	case "string":
+		if c := r.rules["const"]; len(c) > 0 {
+			add("const", v+" === "+c[0], "must equal "+c[0])
+		}
+		// Lengths count code points, not UTF-16 code units.
+		lengths("[..."+v+"].length", "characters")
+		if p := r.rules["pattern"]; len(p) > 0 {
+			add("pattern", g.patternConst(field, p[0])+".test("+v+")", "must match the pattern "+p[0])
+		}
+		if p := r.rules["prefix"]; len(p) > 0 {
+			add("prefix", v+".startsWith("+p[0]+")", "must start with "+p[0])
+		}
+		if s := r.rules["suffix"]; len(s) > 0 {
+			add("suffix", v+".endsWith("+s[0]+")", "must end with "+s[0])
+		}
+		if s := r.rules["contains"]; len(s) > 0 {
+			add("contains", v+".includes("+s[0]+")", "must contain "+s[0])
+		}
+		if s := r.rules["not_contains"]; len(s) > 0 {
+			add("not_contains", "!"+v+".includes("+s[0]+")", "must not contain "+s[0])
+		}
+		inChecks(v)
+	case "bytes":
+		lengths(v+".length", "bytes")
+	case "enum":
+		if c := r.rules["const"]; len(c) > 0 {
+			add("const", v+" === "+c[0], "must equal "+c[0])
+		}
+		if d := r.rules["defined_only"]; len(d) > 0 && d[0] == "true" && *field.Type == descriptor.FieldDescriptorProto_TYPE_ENUM {
+			enum := g.TypeName(g.ObjectNamed(field.GetTypeName()))
+			add("defined_only", enum+"_name["+v+"] !== undefined", "must be a defined value of "+strings.TrimPrefix(field.GetTypeName(), "."))
+		}
+		inChecks(v)
+	case "repeated", "map":
+		size := v + ".length"
+		min, max, unit := "min_items", "max_items", "elements"
+		if r.kind == "map" {
+			min, max, unit = "min_pairs", "max_pairs", "pairs"
+			size = v + ".size"
+			if g.records {
+				size = "Object.keys(" + v + ").length"
+			}
+		}
+		if n := r.rules[min]; len(n) > 0 {
+			add(min, size+" >= "+n[0], "must have at least "+n[0]+" "+unit)
+		}
+		if n := r.rules[max]; len(n) > 0 {
+			add(max, size+" <= "+n[0], "must have at most "+n[0]+" "+unit)
+		}
+		if u := r.rules["unique"]; len(u) > 0 && u[0] == "true" {
+			elems := v
+			if field.GetType() == descriptor.FieldDescriptorProto_TYPE_BYTES {
+				// A set compares arrays by reference, so compare their contents.
+				elems = v + ".map((b) => b.join())"
+			}
+			add("unique", "new Set("+elems+").size === "+v+".length", "must have unique elements")
+		}
+	case "":
+		// Only message rules.
+	default:
+		// Numbers. 64-bit rules are bigint literals, whatever the
+		// representation of the field.
+		if is64(field) {
+			v = g.longToBigInt(v)
+		}
+		lower, upper := bounds(r)
+		for _, c := range comparisons {
+			if lower != "" && upper != "" && (c.rule == lower || c.rule == upper) {
+				continue
+			}
+			if lit := r.rules[c.rule]; len(lit) > 0 {
+				add(c.rule, v+" "+c.op+" "+lit[0], c.message+" "+displayLiteral(lit[0]))
+			}
+		}
+		if lower != "" && upper != "" {
+			checks = append(checks, rangeCheck(r, lower, upper, v))
+		}
+		inChecks(v)
+	}
+	return checks
+}
+
+// patternConst returns the name of the constant holding the RegExp of the
+// pattern literal, declared at the end of the file, so that it is built once.
+// The pattern must be valid RE2 syntax, as protoc-gen-validate requires.
+func (g *Generator) patternConst(field *descriptor.FieldDescriptorProto, lit string) string {
+	if name, ok := g.patterns[lit]; ok {
+		return name
+	}
+	var pattern string
+	if err := json.Unmarshal([]byte(lit), &pattern); err != nil {
+		g.ErrorAt(g.fieldPath(field), err, "reading pattern of", field.GetName())
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		g.ErrorAt(g.fieldPath(field), err, "bad pattern of", field.GetName())
+	}
+	if g.patterns == nil {
+		g.patterns = make(map[string]string)
+	}
+	name := "pattern" + strconv.Itoa(len(g.patterns))
+	g.patterns[lit] = name
+	g.addInitf("const %s = new RegExp(%s, \"u\");", name, lit)
+	return name
+}
+
+// validateCall returns the expression that returns the violations of the
+// message v of the printed type typ, below the given path.
+func (g *Generator) validateCall(typ, v, path string) string {
+	if g.interfaces {
+		return typ + ".validate(" + v + ", " + path + ")"
+	}
+	return v + ".validate(" + path + ")"
+}
+
+// generateChecks generates the statements that report the violations of the
+// checks by the value v of the field at the given path. A non-empty guard is
+// a condition under which the value is checked at all.
+func (g *Generator) generateChecks(checks []check, guard, path string) {
+	if guard != "" {
+		guard += " && "
+	}
+	for _, c := range checks {
+		g.P("if (", guard, "!(", c.cond, ")) {")
+		g.In()
+		g.P("violations.push({ field: ", path, ", rule: ", strconv.Quote(c.rule), ", message: ", jsQuote(c.message), " });")
+		g.Out()
+		g.P("}")
+	}
+}
+
+// generateValidate generates the method that returns the violations of the
+// constraints declared with (validate.rules) on the fields, in the message and
+// the messages it holds.
+func (g *Generator) generateValidate(message *messageDescriptor) {
+	fieldNames := message.fieldNames()
+	runtime := g.Pkg["runtime"]
+	name := strconv.Quote(dottedSlice(message.TypeName()))
+
+	g.P("/**")
+	g.P(" * Returns the violations of the constraints declared on the fields, in")
+	g.P(" * this message and in the messages it holds.")
+	g.P(" */")
+	g.methodHeader(message, false, "validate", "path: string = "+name, runtime+".Violation[]")
+	g.P("const violations: ", runtime, ".Violation[] = [];")
+	for _, field := range message.Field {
+		r := g.rulesOf(field)
+		if r == nil {
+			r = &fieldRules{}
+		}
+		if r.kind == "" && !isMessage(field) {
+			continue
+		}
+		path := "path + " + strconv.Quote("."+field.GetName())
+		v := "m." + fieldNames[field]
+		typ := ""
+		if hasMethods(field) {
+			typ = g.TypeName(g.ObjectNamed(field.GetTypeName()))
+		}
+
+		switch {
+		case inOneof(field):
+			p := "m." + message.oneofName(*field.OneofIndex)
+			guard := p + ".case === " + oneofCase(field)
+			g.generateChecks(g.ruleChecks(field, r, p+".value"), guard, path)
+			if hasMethods(field) && !r.message.skip {
+				g.P("if (", guard, ") {")
+				g.In()
+				g.P("violations.push(...", g.validateCall(typ, p+".value", path), ");")
+				g.Out()
+				g.P("}")
+			}
+		case g.mapEntry(field) != nil:
+			g.generateChecks(g.ruleChecks(field, r, v), "", path)
+			entry := g.mapEntry(field)
+			if valField := entry.Field[1]; hasMethods(valField) && !r.message.skip {
+				typ = g.TypeName(g.ObjectNamed(valField.GetTypeName()))
+				g.P("for (const [k, v] of ", g.mapEntries(v), ") {")
+				g.In()
+				g.P("violations.push(...", g.validateCall(typ, "v", path+" + `[${k}]`"), ");")
+				g.Out()
+				g.P("}")
+			}
+		case isRepeated(field):
+			g.generateChecks(g.ruleChecks(field, r, v), "", path)
+			items := r.items
+			if items == nil {
+				items = &fieldRules{}
+			}
+			itemChecks := g.ruleChecks(field, items, "v")
+			recurse := hasMethods(field) && !items.message.skip
+			if len(itemChecks) == 0 && !recurse {
+				continue
+			}
+			g.P(v, ".forEach((v, i) => {")
+			g.In()
+			g.generateChecks(itemChecks, "", path+" + `[${i}]`")
+			if recurse {
+				g.P("violations.push(...", g.validateCall(typ, "v", path+" + `[${i}]`"), ");")
+			}
+			g.Out()
+			g.P("});")
+		case isMessage(field):
+			if r.message.required {
+				g.P("if (", v, " === undefined) {")
+				g.In()
+				g.P("violations.push({ field: ", path, ", rule: \"message.required\", message: \"must be set\" });")
+				g.Out()
+				g.P("}")
+			}
+			if name := wellKnown(field); name != "" {
+				// The rules of a wrapper apply to the value it wraps.
+				g.generateChecks(g.ruleChecks(field, r, g.wellKnownIn(name, v)), v+" !== undefined", path)
+			} else if !r.message.skip {
+				g.P("if (", v, " !== undefined) {")
+				g.In()
+				g.P("violations.push(...", g.validateCall(typ, v, path), ");")
+				g.Out()
+				g.P("}")
+			}
+		case g.hasPresence(message, field):
+			// Unset fields are not checked.
+			ref := g.presenceRef(message, field, "m")
+			g.generateChecks(g.ruleChecks(field, r, ref), ref+" !== undefined", path)
+		default:
+			g.generateChecks(g.ruleChecks(field, r, v), "", path)
+		}
+	}
+	g.P("return violations;")
+	g.methodEnd()
+}
@@ -0,0 +1,243 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// withRules returns the field with the encoding of a validate.FieldRules
+// message as its (validate.rules) option.
+func withRules(t *testing.T, f *descriptor.FieldDescriptorProto, rules ...byte) *descriptor.FieldDescriptorProto {
+	t.Helper()
+	// Field 1071, length-delimited.
+	b := append([]byte{0xfa, 0x42, byte(len(rules))}, rules...)
+	f.Options = new(descriptor.FieldOptions)
+	if err := proto.Unmarshal(b, f.Options); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestValidateReadsRules(t *testing.T) {
+	// (validate.rules).int32 = {gt: 1}
+	count := withRules(t, testField("count", 1, optional, typeInt32), 0x1a, 0x02, 0x20, 0x01)
+	out := generate(t, "", testFile("rules.proto", "proto3", testMessage("Limited", count)))
+	wantContains(t, out, "!(m.count > 1)", `rule: "int32.gt"`, `"must be greater than 1"`)
+}
+
+func TestParseFieldRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []byte
+		want  *fieldRules
+	}{{
+		name: "int32",
+		// int32 = {gt: 1, lt: 5}
+		rules: []byte{0x1a, 0x04, 0x20, 0x01, 0x10, 0x05},
+		want:  &fieldRules{kind: "int32", rules: map[string][]string{"gt": {"1"}, "lt": {"5"}}},
+	}, {
+		name: "packed int64 in",
+		// int64 = {in: [1, 2]}, packed
+		rules: []byte{0x22, 0x04, 0x32, 0x02, 0x01, 0x02},
+		want:  &fieldRules{kind: "int64", rules: map[string][]string{"in": {"1n", "2n"}}},
+	}, {
+		name: "string pattern",
+		// string = {pattern: "^a+$"}
+		rules: []byte{0x72, 0x06, 0x32, 0x04, '^', 'a', '+', '$'},
+		want:  &fieldRules{kind: "string", rules: map[string][]string{"pattern": {`"^a+$"`}}},
+	}, {
+		name: "message required",
+		// message = {required: true}
+		rules: []byte{0x8a, 0x01, 0x02, 0x10, 0x01},
+		want:  &fieldRules{message: messageRules{required: true}, rules: map[string][]string{}},
+	}, {
+		name: "repeated items",
+		// repeated = {min_items: 1, items: {string: {min_len: 2}}}
+		rules: []byte{0x92, 0x01, 0x08, 0x08, 0x01, 0x22, 0x04, 0x72, 0x02, 0x10, 0x02},
+		want: &fieldRules{kind: "repeated", rules: map[string][]string{"min_items": {"1"}},
+			items: &fieldRules{kind: "string", rules: map[string][]string{"min_len": {"2"}}}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFieldRules(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRuleChecks(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		label descriptor.FieldDescriptorProto_Label
+		typ   descriptor.FieldDescriptorProto_Type
+		rules *fieldRules
+		want  []check
+	}{{
+		name:  "int32 range",
+		field: "count",
+		label: optional,
+		typ:   typeInt32,
+		rules: &fieldRules{kind: "int32", rules: map[string][]string{"gt": {"1"}, "lt": {"5"}}},
+		want: []check{
+			{"v > 1 && v < 5", "int32.gt_lt", "must be inside range (1, 5)"},
+		},
+	}, {
+		name:  "int32 exclusive range",
+		field: "count",
+		label: optional,
+		typ:   typeInt32,
+		rules: &fieldRules{kind: "int32", rules: map[string][]string{"gt": {"5"}, "lte": {"1"}}},
+		want: []check{
+			{"v > 5 || v <= 1", "int32.gt_lte_exclusive", "must be outside range (1, 5]"},
+		},
+	}, {
+		name:  "int64 bound",
+		field: "id",
+		label: optional,
+		typ:   typeInt64,
+		rules: &fieldRules{kind: "int64", rules: map[string][]string{"gte": {"10n"}, "in": {"10n", "20n"}}},
+		want: []check{
+			{"v >= 10n", "int64.gte", "must be greater than or equal to 10"},
+			{"[10n, 20n].includes(v)", "int64.in", "must be one of 10, 20"},
+		},
+	}, {
+		name:  "string",
+		field: "name",
+		label: optional,
+		typ:   typeString,
+		rules: &fieldRules{kind: "string", rules: map[string][]string{"min_len": {"2"}, "prefix": {`"a"`}}},
+		want: []check{
+			{"[...v].length >= 2", "string.min_len", "must be at least 2 characters long"},
+			{`v.startsWith("a")`, "string.prefix", `must start with "a"`},
+		},
+	}, {
+		name:  "repeated unique",
+		field: "tags",
+		label: repeated,
+		typ:   typeString,
+		rules: &fieldRules{kind: "repeated", rules: map[string][]string{"min_items": {"1"}, "unique": {"true"}}},
+		want: []check{
+			{"v.length >= 1", "repeated.min_items", "must have at least 1 elements"},
+			{"new Set(v).size === v.length", "repeated.unique", "must have unique elements"},
+		},
+	}, {
+		name:  "repeated unique bytes",
+		field: "blobs",
+		label: repeated,
+		typ:   typeBytes,
+		rules: &fieldRules{kind: "repeated", rules: map[string][]string{"unique": {"true"}}},
+		want: []check{
+			{"new Set(v.map((b) => b.join())).size === v.length", "repeated.unique", "must have unique elements"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator()
+			g.CommandLineParameters("")
+			got := g.ruleChecks(testField(tt.field, 1, tt.label, tt.typ), tt.rules, "v")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ruleChecks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNested(t *testing.T) {
+	inner := testMessage("Inner", testField("id", 1, optional, typeInt32))
+	outer := testMessage("Outer",
+		testTypedField("inner", 1, typeMessage, ".test.Inner"),
+		testField("name", 2, optional, typeString))
+	out := generate(t, "", testFile("nested.proto", "proto3", inner, outer))
+	wantContains(t, out,
+		"validate(path: string = \"Outer\"): pb.Violation[] {",
+		"if (m.inner !== undefined) {\n\t\t\tviolations.push(...m.inner.validate(path + \".inner\"));",
+		"validate(_path: string = \"Inner\"): pb.Violation[] {",
+	)
+	wantLacks(t, out, "path + \".name\"", "path + \".id\"")
+}
+
+func TestValidateRuleOutput(t *testing.T) {
+	tests := []struct {
+		name  string
+		field *descriptor.FieldDescriptorProto
+		rules []byte
+		want  []string
+		lacks []string
+	}{{
+		name:  "range",
+		field: testField("count", 1, optional, typeInt32),
+		// int32 = {gt: 1, lt: 5}
+		rules: []byte{0x1a, 0x04, 0x20, 0x01, 0x10, 0x05},
+		want:  []string{"!(m.count > 1 && m.count < 5)", `rule: "int32.gt_lt"`, `"must be inside range (1, 5)"`},
+		lacks: []string{`rule: "int32.gt"`, `rule: "int32.lt"`},
+	}, {
+		name:  "bigint in",
+		field: testField("id", 1, optional, typeInt64),
+		// int64 = {in: [1, 2]}
+		rules: []byte{0x22, 0x04, 0x30, 0x01, 0x30, 0x02},
+		want:  []string{"[1n, 2n].includes(m.id)", `"must be one of 1, 2"`},
+		lacks: []string{"one of 1n"},
+	}, {
+		name:  "unique bytes",
+		field: testField("blobs", 1, repeated, typeBytes),
+		// repeated = {unique: true}
+		rules: []byte{0x92, 0x01, 0x02, 0x18, 0x01},
+		want:  []string{"new Set(m.blobs.map((b) => b.join())).size === m.blobs.length", `"must have unique elements"`},
+		lacks: []string{"new Set(m.blobs).size"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := withRules(t, tt.field, tt.rules...)
+			out := generate(t, "", testFile("rules.proto", "proto3", testMessage("Limited", f)))
+			wantContains(t, out, tt.want...)
+			wantLacks(t, out, tt.lacks...)
+		})
+	}
+}
+
+func TestValidateMapPairs(t *testing.T) {
+	limited := testMessage("Limited")
+	addMap(limited, "counts", 1, typeString, typeInt32)
+	// map = {min_pairs: 2}
+	withRules(t, limited.Field[0], 0x9a, 0x01, 0x02, 0x08, 0x02)
+
+	out := generate(t, "", testFile("rules.proto", "proto3", limited))
+	wantContains(t, out, "!(m.counts.size >= 2)", `"must have at least 2 pairs"`)
+	wantLacks(t, out, "2 elements")
+}
+
+func TestValidatePatterns(t *testing.T) {
+	// string = {pattern: "^a+$"}
+	name := withRules(t, testField("name", 1, optional, typeString), 0x72, 0x06, 0x32, 0x04, '^', 'a', '+', '$')
+	alias := withRules(t, testField("alias", 2, optional, typeString), 0x72, 0x06, 0x32, 0x04, '^', 'a', '+', '$')
+	out := generate(t, "", testFile("rules.proto", "proto3", testMessage("Named", name, alias)))
+	wantContains(t, out,
+		"if (!(pattern0.test(m.name))) {",
+		"if (!(pattern0.test(m.alias))) {",
+		`const pattern0 = new RegExp("^a+$", "u");`,
+	)
+	wantLacks(t, out, "pattern1", "new RegExp(\"^a+$\", \"u\").test")
+
+	// string = {pattern: "(a"}
+	bad := withRules(t, testField("name", 1, optional, typeString), 0x72, 0x04, 0x32, 0x02, '(', 'a')
+	file := testFile("rules.proto", "proto3", testMessage("Named", bad))
+	file.SourceCodeInfo = &descriptor.SourceCodeInfo{
+		Location: []*descriptor.SourceCodeInfo_Location{
+			{Path: []int32{messagePath, 0, messageFieldPath, 0}, Span: []int32{6, 2, 50}},
+		},
+	}
+	_, err := Generate(request("", file), Options{})
+	want := "rules.proto:7:3: bad pattern of name: error parsing regexp: missing closing ): `(a`"
+	if err == nil || err.Error() != want {
+		t.Errorf("Generate returned error %v, want %q", err, want)
+	}
+}
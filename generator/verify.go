@@ -0,0 +1,141 @@
+package generator
+
+import "strconv"
+
+// hasRequired returns whether the message or any message it holds, however
+// deeply, has a required field. Decoding such a message checks its required
+// fields.
+func (g *Generator) hasRequired(message *messageDescriptor) bool {
+	return g.hasRequiredSeen(message, make(map[*messageDescriptor]bool))
+}
+
+func (g *Generator) hasRequiredSeen(message *messageDescriptor, seen map[*messageDescriptor]bool) bool {
+	if seen[message] {
+		return false
+	}
+	seen[message] = true
+	for _, field := range message.Field {
+		if isRequired(field) {
+			return true
+		}
+		if !hasMethods(field) {
+			continue
+		}
+		obj := g.ObjectNamed(field.GetTypeName())
+		if id, ok := obj.(*importDescriptor); ok {
+			obj = id.o
+		}
+		if desc, ok := obj.(*messageDescriptor); ok && g.hasRequiredSeen(desc, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyCall returns the expression that returns the missing required fields
+// of the message v of the printed type typ, below the given path or below the
+// name of the message if path is empty.
+func (g *Generator) verifyCall(typ, v, path string) string {
+	if g.interfaces {
+		if path != "" {
+			path = ", " + path
+		}
+		return typ + ".verify(" + v + path + ")"
+	}
+	return v + ".verify(" + path + ")"
+}
+
+// generateVerify generates the method that returns the full path of every
+// required field that is not set, in the message and the messages it holds.
+func (g *Generator) generateVerify(message *messageDescriptor) {
+	fieldNames := message.fieldNames()
+	name := strconv.Quote(dottedSlice(message.TypeName()))
+
+	g.P("/**")
+	g.P(" * Returns the path of every required field that is not set, in this")
+	g.P(" * message and in the messages it holds, such as \"Request.key\".")
+	g.P(" */")
+	g.methodHeader(message, false, "verify", "path: string = "+name, "string[]")
+	g.P("const missing: string[] = [];")
+	for _, field := range message.Field {
+		if !isRequired(field) && !hasMethods(field) {
+			continue
+		}
+		path := "path + " + strconv.Quote("."+field.GetName())
+		typ := ""
+		if hasMethods(field) {
+			typ = g.TypeName(g.ObjectNamed(field.GetTypeName()))
+		}
+
+		switch {
+		case inOneof(field):
+			p := "m." + message.oneofName(*field.OneofIndex)
+			g.P("if (", p, ".case === ", oneofCase(field), ") {")
+			g.In()
+			g.P("missing.push(...", g.verifyCall(typ, p+".value", path), ");")
+			g.Out()
+			g.P("}")
+		case g.mapEntry(field) != nil:
+			entry := g.mapEntry(field)
+			if !hasMethods(entry.Field[1]) {
+				continue
+			}
+			typ = g.TypeName(g.ObjectNamed(entry.Field[1].GetTypeName()))
+			g.P("for (const [k, v] of ", g.mapEntries("m."+fieldNames[field]), ") {")
+			g.In()
+			g.P("missing.push(...", g.verifyCall(typ, "v", path+" + `[${k}]`"), ");")
+			g.Out()
+			g.P("}")
+		case isRepeated(field):
+			g.P("m.", fieldNames[field], ".forEach((v, i) => {")
+			g.In()
+			g.P("missing.push(...", g.verifyCall(typ, "v", path+" + `[${i}]`"), ");")
+			g.Out()
+			g.P("});")
+		case hasMethods(field):
+			v := "m." + fieldNames[field]
+			g.P("if (", v, " !== undefined) {")
+			g.In()
+			g.P("missing.push(...", g.verifyCall(typ, v, path), ");")
+			g.Out()
+			if isRequired(field) {
+				g.P("} else {")
+				g.In()
+				g.P("missing.push(", path, ");")
+				g.Out()
+			}
+			g.P("}")
+		default:
+			ref := "m." + fieldNames[field]
+			if !isMessage(field) {
+				ref = g.presenceRef(message, field, "m")
+			}
+			g.P("if (", ref, " === undefined) {")
+			g.In()
+			g.P("missing.push(", path, ");")
+			g.Out()
+			g.P("}")
+		}
+	}
+	g.P("return missing;")
+	g.methodEnd()
+}
+
+// generateDecodeCheck generates the check at the end of decoding that the
+// required fields are set. Only the outermost message is checked, since it
+// verifies the messages it holds.
+func (g *Generator) generateDecodeCheck(message *messageDescriptor) {
+	if !g.hasRequired(message) {
+		return
+	}
+	g.P("if (length === undefined) {")
+	g.In()
+	g.P("const missing = ", g.verifyCall(message.GetName(), "m", ""), ";")
+	g.P("if (missing.length > 0) {")
+	g.In()
+	g.P("throw new ", g.Pkg["runtime"], ".RequiredFieldError(missing);")
+	g.Out()
+	g.P("}")
+	g.Out()
+	g.P("}")
+}
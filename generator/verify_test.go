@@ -0,0 +1,22 @@
+package generator
+
+import "testing"
+
+func TestVerifyRequired(t *testing.T) {
+	inner := testMessage("Inner", testField("id", 1, required, typeInt32))
+	outer := testMessage("Outer",
+		testField("name", 1, required, typeString),
+		testTypedField("inner", 2, typeMessage, ".test.Inner"),
+		testField("note", 3, optional, typeString))
+	out := generate(t, "", testFile("req.proto", "proto2", inner, outer))
+	wantContains(t, out,
+		"verify(path: string = \"Outer\"): string[] {",
+		"if (m._name === undefined) {\n\t\t\tmissing.push(path + \".name\");",
+		"if (m.inner !== undefined) {\n\t\t\tmissing.push(...m.inner.verify(path + \".inner\"));",
+		"verify(path: string = \"Inner\"): string[] {",
+		"missing.push(path + \".id\");",
+		"if (length === undefined) {\n\t\t\tconst missing = m.verify();",
+		"throw new pb.RequiredFieldError(missing);",
+	)
+	wantLacks(t, out, "path + \".note\"")
+}
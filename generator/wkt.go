@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// Fields of some well-known messages of the google.protobuf package have
+// native TypeScript types, such as Date for Timestamp, instead of the
+// generated classes. They are converted by the runtime, so the files
+// declaring them need not be generated.
+
+// Names of the well-known messages with native types.
+var wellKnownTypes = map[string]bool{
+	"Any":       true,
+	"Duration":  true,
+	"Struct":    true,
+	"Timestamp": true,
+
+	"Value":       true,
+	"ListValue":   true,
+	"DoubleValue": true,
+	"FloatValue":  true,
+	"Int64Value":  true,
+	"UInt64Value": true,
+	"Int32Value":  true,
+	"UInt32Value": true,
+	"BoolValue":   true,
+	"StringValue": true,
+	"BytesValue":  true,
+}
+
+// Files declaring only well-known messages with native types. They are not
+// imported unless something else they declare is used.
+var wellKnownFiles = map[string]bool{
+	"google/protobuf/any.proto":       true,
+	"google/protobuf/duration.proto":  true,
+	"google/protobuf/struct.proto":    true,
+	"google/protobuf/timestamp.proto": true,
+	"google/protobuf/wrappers.proto":  true,
+}
+
+// wellKnown returns the name of the well-known message held by the field if
+// it has a native type, or "".
+func wellKnown(field *descriptor.FieldDescriptorProto) string {
+	if !isMessage(field) {
+		return ""
+	}
+	name := strings.TrimPrefix(field.GetTypeName(), ".google.protobuf.")
+	if name == field.GetTypeName() || !wellKnownTypes[name] {
+		return ""
+	}
+	return name
+}
+
+// hasMethods returns whether the field holds a message of a generated type,
+// which has the methods to verify and validate it.
+func hasMethods(field *descriptor.FieldDescriptorProto) bool {
+	return isMessage(field) && wellKnown(field) == ""
+}
+
+// wellKnownType returns the native type of the well-known message.
+func (g *Generator) wellKnownType(name string) string {
+	runtime := g.Pkg["runtime"]
+	switch name {
+	case "Any":
+		return runtime + ".Any"
+	case "Timestamp":
+		return "Date"
+	case "Duration":
+		return runtime + ".Duration"
+	case "Struct":
+		return runtime + ".JSONObject"
+	case "Value":
+		return runtime + ".JSONValue"
+	case "ListValue":
+		return runtime + ".JSONValue[]"
+	case "Int64Value", "UInt64Value":
+		return g.long
+	case "BoolValue":
+		return "boolean"
+	case "StringValue":
+		return "string"
+	case "BytesValue":
+		return "Uint8Array"
+	}
+	return "number"
+}
+
+// wellKnownCodec returns the runtime codec of the well-known message. The
+// static methods of Any are its codec.
+func (g *Generator) wellKnownCodec(name string) string {
+	if name == "Any" {
+		return g.Pkg["runtime"] + ".Any"
+	}
+	return g.Pkg["runtime"] + ".wkt." + name
+}
+
+// wellKnownIn returns the native value v converted to the value its codec
+// expects, which differs for the 64-bit wrappers.
+func (g *Generator) wellKnownIn(name, v string) string {
+	if name == "Int64Value" || name == "UInt64Value" {
+		return g.longToBigInt(v)
+	}
+	return v
+}
+
+// wellKnownOut returns the value v returned by the codec of the well-known
+// message converted to its native type.
+func (g *Generator) wellKnownOut(name, v string) string {
+	if name == "Int64Value" || name == "UInt64Value" {
+		return g.longFromBigInt(v)
+	}
+	return v
+}
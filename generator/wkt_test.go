@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// wellKnownFile returns a file of the google.protobuf package declaring the
+// message with a single field.
+func wellKnownFile(name, message string, typ descriptor.FieldDescriptorProto_Type) *descriptor.FileDescriptorProto {
+	f := testFile(name, "proto3", testMessage(message, testField("value", 1, optional, typ)))
+	f.Package = proto.String("google.protobuf")
+	return f
+}
+
+func TestWellKnownTypes(t *testing.T) {
+	timestamp := wellKnownFile("google/protobuf/timestamp.proto", "Timestamp", typeInt32)
+	wrappers := wellKnownFile("google/protobuf/wrappers.proto", "StringValue", typeString)
+	event := testFile("event.proto", "proto3", testMessage("Event",
+		testTypedField("at", 1, typeMessage, ".google.protobuf.Timestamp"),
+		testTypedField("label", 2, typeMessage, ".google.protobuf.StringValue")))
+	event.Dependency = []string{timestamp.GetName(), wrappers.GetName()}
+
+	out := generate(t, "", timestamp, wrappers, event)
+	wantContains(t, out,
+		"at: Date | undefined = undefined;",
+		"label: string | undefined = undefined;",
+		"pb.wkt.Timestamp.encode(m.at, w.tag(1, 2).fork()).ldelim();",
+		"m.label = pb.wkt.StringValue.decode(r, r.uint32());",
+		"o[\"at\"] = pb.wkt.Timestamp.toJSON(m.at);",
+		"m.label = pb.wkt.StringValue.fromJSON(v, options);",
+	)
+	wantLacks(t, out, "timestamp.pb", "wrappers.pb", "m.at.verify", "m.label.validate")
+}
+
+func TestAny(t *testing.T) {
+	any := wellKnownFile("google/protobuf/any.proto", "Any", typeBytes)
+	event := testFile("event.proto", "proto3", testMessage("Event",
+		testTypedField("payload", 1, typeMessage, ".google.protobuf.Any")))
+	event.Dependency = []string{any.GetName()}
+
+	out := generate(t, "", any, event)
+	wantContains(t, out,
+		"payload: pb.Any | undefined = undefined;",
+		"pb.Any.encode(m.payload, w.tag(1, 2).fork()).ldelim();",
+		"m.payload = pb.Any.decode(r, r.uint32());",
+		"m.payload = pb.Any.fromJSON(v, options);",
+		"pb.registry.register(\"test.Event\", Event);",
+	)
+	wantLacks(t, out, "any.pb", "pb.wkt.Any")
+}
@@ -0,0 +1,241 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// In wsrpc mode every service also gets a client bound to a WebSocket, and
+// each file with services gets a Go file with the matching server-side
+// dispatcher. Calls are framed as described by runtime/wsrpc.proto, with
+// methods identified by the full name of their service and their position in
+// it, starting at 1.
+
+// generateWSClient generates the client of the service that sends its calls
+// over a WebSocket.
+func (g *Generator) generateWSClient(service *descriptor.ServiceDescriptorProto) {
+	name := service.GetName()
+	transport := g.Pkg["runtime"] + ".WebSocketTransport"
+
+	g.P("/**")
+	g.P(" * Client of the ", name, " service bound to a WebSocket. Pass a transport")
+	g.P(" * instead of a socket to share one socket among several clients.")
+	g.P(" */")
+	g.P("export class ", name, "WebSocketClient extends ", name, "ClientImpl {")
+	g.In()
+	g.P("constructor(socket: WebSocket | ", transport, ") {")
+	g.In()
+	g.P("super(socket instanceof ", transport, " ? socket : new ", transport, "(socket));")
+	g.Out()
+	g.P("}")
+	g.Out()
+	g.P("}")
+	g.P()
+}
+
+// wsDispatcherFileName returns the name of the Go file holding the
+// dispatchers of the services in the file.
+func (d *fileDescriptor) wsDispatcherFileName() string {
+	name := *d.Name
+	if ext := path.Ext(name); ext == ".proto" || ext == ".protodevel" {
+		name = name[:len(name)-len(ext)]
+	}
+	return name + ".wsrpc.go"
+}
+
+// goPackageOf returns the Go import path and package name under which
+// protoc-gen-go generates the file. The import path is empty unless the
+// go_package option declares one.
+func goPackageOf(fd *descriptor.FileDescriptorProto) (impPath, pkg string) {
+	if opt := fd.GetOptions().GetGoPackage(); opt != "" {
+		// A semicolon-delimited suffix overrides the package name.
+		if sc := strings.IndexByte(opt, ';'); sc >= 0 {
+			return opt[:sc], opt[sc+1:]
+		}
+		// The presence of a slash implies there's an import path.
+		if slash := strings.LastIndex(opt, "/"); slash >= 0 {
+			return opt, opt[slash+1:]
+		}
+		return "", opt
+	}
+	if pkg := fd.GetPackage(); pkg != "" {
+		return "", strings.Map(badToUnderscore, pkg)
+	}
+	return "", strings.Map(badToUnderscore, baseName(fd.GetName()))
+}
+
+// generateWSDispatcher generates the Go server-side dispatcher for every
+// service of the current file: the method IDs, a server interface and a
+// function that routes a call to the server. The file belongs in the package
+// of the Go code protoc-gen-go generates for the same file.
+func (g *Generator) generateWSDispatcher() {
+	_, pkg := goPackageOf(g.file.FileDescriptorProto)
+	imports := map[string]string{
+		"context":                          "context",
+		"fmt":                              "fmt",
+		"github.com/golang/protobuf/proto": "proto",
+	}
+
+	// goType returns the Go name of a message as protoc-gen-go declares it.
+	goType := func(typeName string) string {
+		obj := g.ObjectNamed(typeName)
+		if id, ok := obj.(*importDescriptor); ok {
+			obj = id.o
+		}
+		name := "*" + CamelCaseSlice(obj.TypeName())
+		impPath, objPkg := goPackageOf(obj.File())
+		if objPkg == pkg {
+			return name
+		}
+		if impPath == "" {
+			g.FailAt(g.typeUsePath(typeName), "wsrpc: no go_package import path for", obj.File().GetName())
+		}
+		imports[impPath] = objPkg
+		return "*" + objPkg + "." + name[1:]
+	}
+
+	for _, service := range g.file.Service {
+		name := service.GetName()
+		fullName := name
+		if g.file.Package != nil {
+			fullName = *g.file.Package + "." + fullName
+		}
+		id := func(method *descriptor.MethodDescriptorProto) string {
+			return name + "_" + method.GetName() + "_WSMethodID"
+		}
+
+		g.P("// ", name, "_WSServiceName is the full name of the ", name, " service, as framed by")
+		g.P("// the WebSocket transport.")
+		g.P("const ", name, "_WSServiceName = ", strconv.Quote(fullName))
+		g.P()
+		g.P("// Method IDs of the ", name, " service, as framed by the WebSocket transport.")
+		g.P("const (")
+		for i, method := range service.Method {
+			g.P(id(method), " uint32 = ", i+1)
+		}
+		g.P(")")
+		g.P()
+
+		g.P("// ", name, "WSStream carries the messages of one call to the ", name, " service.")
+		g.P("// The WebSocket server implements it for every call it dispatches.")
+		g.P("type ", name, "WSStream interface {")
+		g.P("// Context is canceled when the client cancels the call.")
+		g.P("Context() context.Context")
+		g.P("// Recv decodes the payload of the next request frame into m.")
+		g.P("// It returns io.EOF once the client has ended its side of the call.")
+		g.P("Recv(m proto.Message) error")
+		g.P("// Send encodes m as the payload of a response frame.")
+		g.P("Send(m proto.Message) error")
+		g.P("}")
+		g.P()
+
+		g.P("// ", name, "WSServer is the server API for the ", name, " service.")
+		g.P("type ", name, "WSServer interface {")
+		for _, method := range service.Method {
+			in, out := goType(method.GetInputType()), goType(method.GetOutputType())
+			stream := name + "WSStream"
+			switch {
+			case method.GetClientStreaming() && method.GetServerStreaming():
+				g.P(method.GetName(), "(", stream, ") error")
+			case method.GetClientStreaming():
+				g.P(method.GetName(), "(", stream, ") (", out, ", error)")
+			case method.GetServerStreaming():
+				g.P(method.GetName(), "(", in, ", ", stream, ") error")
+			default:
+				g.P(method.GetName(), "(context.Context, ", in, ") (", out, ", error)")
+			}
+		}
+		g.P("}")
+		g.P()
+
+		g.P("// Dispatch", name, " calls the method of srv identified by service and id,")
+		g.P("// reading requests from and writing responses to the stream of the call.")
+		g.P("// The service must be ", name, "_WSServiceName.")
+		g.P("func Dispatch", name, "(srv ", name, "WSServer, service string, id uint32, stream ", name, "WSStream) error {")
+		g.P("if service != ", name, "_WSServiceName {")
+		g.P("return fmt.Errorf(\"wsrpc: call of service %q dispatched to ", fullName, "\", service)")
+		g.P("}")
+		g.P("switch id {")
+		for _, method := range service.Method {
+			in := goType(method.GetInputType())
+			g.P("case ", id(method), ":")
+			if !method.GetClientStreaming() {
+				g.P("in := new(", in[1:], ")")
+				g.P("if err := stream.Recv(in); err != nil {")
+				g.P("return err")
+				g.P("}")
+			}
+			switch {
+			case method.GetClientStreaming() && method.GetServerStreaming():
+				g.P("return srv.", method.GetName(), "(stream)")
+			case method.GetClientStreaming():
+				g.P("out, err := srv.", method.GetName(), "(stream)")
+			case method.GetServerStreaming():
+				g.P("return srv.", method.GetName(), "(in, stream)")
+			default:
+				g.P("out, err := srv.", method.GetName(), "(stream.Context(), in)")
+			}
+			if !method.GetServerStreaming() {
+				g.P("if err != nil {")
+				g.P("return err")
+				g.P("}")
+				g.P("return stream.Send(out)")
+			}
+		}
+		g.P("default:")
+		g.P("return fmt.Errorf(\"wsrpc: unknown method %d of ", fullName, "\", id)")
+		g.P("}")
+		g.P("}")
+		g.P()
+	}
+
+	// Generate the header and imports last, though they appear first in the output.
+	rem := g.Buffer
+	g.Buffer = new(bytes.Buffer)
+	g.P("// Code generated by protoc-gen-ts. DO NOT EDIT.")
+	g.P("// source: ", g.file.Name)
+	g.P()
+	g.P("package ", pkg)
+	g.P()
+	var paths []string
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	g.P("import (")
+	for _, p := range paths {
+		if path.Base(p) == imports[p] {
+			g.P(strconv.Quote(p))
+		} else {
+			g.P(imports[p], " ", strconv.Quote(p))
+		}
+	}
+	g.P(")")
+	g.P()
+	g.Write(rem.Bytes())
+
+	// Reformat generated code.
+	raw := g.Bytes()
+	src, err := format.Source(raw)
+	if err != nil {
+		// Print out the bad code with line numbers.
+		// This should never happen in practice, but it can while changing generated code,
+		// so consider this a debugging aid.
+		var lines bytes.Buffer
+		s := bufio.NewScanner(bytes.NewReader(raw))
+		for line := 1; s.Scan(); line++ {
+			fmt.Fprintf(&lines, "%5d\t%s\n", line, s.Bytes())
+		}
+		g.Fail("bad Go source code was generated:", err.Error(), "\n"+lines.String())
+	}
+	g.Reset()
+	g.Write(src)
+}
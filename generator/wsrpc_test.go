@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"go/format"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+func TestWebSocketRPC(t *testing.T) {
+	file := testServiceFile()
+	file.Options = &descriptor.FileOptions{GoPackage: proto.String("example.com/grpc;grpcpb")}
+
+	out := generate(t, "", file)
+	wantLacks(t, out, "WebSocketClient")
+
+	files := generateFiles(t, "wsrpc", file)
+	wantContains(t, files["grpc.pb.ts"],
+		"export class TestWebSocketClient extends TestClientImpl {",
+		"constructor(socket: WebSocket | pb.WebSocketTransport) {",
+	)
+	dispatcher, ok := files["grpc.wsrpc.go"]
+	if !ok {
+		t.Fatalf("no dispatcher among %d files", len(files))
+	}
+	wantContains(t, dispatcher,
+		"package grpcpb",
+		"case Test_UnaryCall_WSMethodID:",
+		"case Test_Bidi_WSMethodID:",
+		"UnaryCall(context.Context, *Request) (*Response, error)",
+		"Downstream(*Request, TestWSStream) error",
+		"Upstream(TestWSStream) (*Response, error)",
+		"Bidi(TestWSStream) error",
+		`const Test_WSServiceName = "test.Test"`,
+		"func DispatchTest(srv TestWSServer, service string, id uint32, stream TestWSStream) error {",
+		"if service != Test_WSServiceName {",
+	)
+	formatted, err := format.Source([]byte(dispatcher))
+	if err != nil {
+		t.Fatalf("dispatcher is not valid Go: %v\n%s", err, dispatcher)
+	}
+	if string(formatted) != dispatcher {
+		t.Errorf("dispatcher is not gofmt-formatted:\n%s", dispatcher)
+	}
+}
@@ -0,0 +1,178 @@
+import * as json from "./json";
+import type { JSONObject, JSONReadOptions, JSONValue } from "./json";
+import { Reader } from "./reader";
+import type { MessageType } from "./registry";
+import { Registry, registry as defaultRegistry } from "./registry";
+import { WireType } from "./wire";
+import * as wkt from "./wkt";
+import { Writer } from "./writer";
+
+/** The prefix of the type URLs of packed messages, unless another is given. */
+export const defaultTypeUrlPrefix = "type.googleapis.com/";
+
+/** Returns the full name of the type in the URL: the part after the last slash. */
+export function typeName(typeUrl: string): string {
+   return typeUrl.slice(typeUrl.lastIndexOf("/") + 1);
+}
+
+/**
+ * Returns the well-known types with native types by full name. They are not in
+ * the registry, and their JSON form in an Any is {"@type": ..., "value": ...}.
+ */
+function wellKnownTypes(): { [name: string]: MessageType } {
+   return {
+      "google.protobuf.Any": Any,
+      "google.protobuf.Duration": wkt.Duration,
+      "google.protobuf.Struct": wkt.Struct,
+      "google.protobuf.Timestamp": wkt.Timestamp,
+      "google.protobuf.Value": wkt.Value,
+      "google.protobuf.ListValue": wkt.ListValue,
+      "google.protobuf.DoubleValue": wkt.DoubleValue,
+      "google.protobuf.FloatValue": wkt.FloatValue,
+      "google.protobuf.Int64Value": wkt.Int64Value,
+      "google.protobuf.UInt64Value": wkt.UInt64Value,
+      "google.protobuf.Int32Value": wkt.Int32Value,
+      "google.protobuf.UInt32Value": wkt.UInt32Value,
+      "google.protobuf.BoolValue": wkt.BoolValue,
+      "google.protobuf.StringValue": wkt.StringValue,
+      "google.protobuf.BytesValue": wkt.BytesValue,
+   };
+}
+
+/** Returns the type with the full name, which must be known. */
+function resolve(name: string, registry: Registry): MessageType {
+   const type = wellKnownTypes()[name] ?? registry.lookup(name);
+   if (type === undefined) {
+      throw new Error(`unknown message type ${name} in google.protobuf.Any`);
+   }
+   return type;
+}
+
+/** Returns the full name of the type, which must be known. */
+function nameOf(type: MessageType, registry: Registry): string {
+   for (const [name, t] of Object.entries(wellKnownTypes())) {
+      if (t === type) {
+         return name;
+      }
+   }
+   const name = registry.nameOf(type);
+   if (name === undefined) {
+      throw new Error("pack of a message whose type is not registered");
+   }
+   return name;
+}
+
+/**
+ * Writes the message of the type. Types of generated interfaces have an
+ * encode function; instances of generated classes have an encode method.
+ */
+function encode(type: MessageType, m: unknown): Uint8Array {
+   const w = type.encode ? type.encode(m) : (m as { encode(): Writer }).encode();
+   return w.finish();
+}
+
+function toJSON(type: MessageType, m: unknown): JSONValue {
+   return type.toJSON ? type.toJSON(m) : (m as { toJSON(): JSONValue }).toJSON();
+}
+
+/**
+ * Any holds google.protobuf.Any: an encoded message of any type, and the URL
+ * whose last segment is the full name of the type.
+ */
+export class Any {
+   constructor(public typeUrl: string = "", public value: Uint8Array = new Uint8Array(0)) {}
+
+   /**
+    * Packs the message. An instance of a generated class is of the class it
+    * was created with; other messages need their type. The type must be
+    * registered, unless it is a well-known type.
+    */
+   static pack<T>(message: T, type?: MessageType<T>, prefix = defaultTypeUrlPrefix, registry = defaultRegistry): Any {
+      const t = type ?? ((message as unknown as object).constructor as MessageType<T>);
+      return new Any(prefix + nameOf(t, registry), encode(t, message));
+   }
+
+   /** Decodes the packed message, whose type must be registered. */
+   unpack<T = unknown>(registry = defaultRegistry): T {
+      return resolve(typeName(this.typeUrl), registry).decode(this.value) as T;
+   }
+
+   /** Returns whether the packed message has the type. */
+   is(type: MessageType, registry = defaultRegistry): boolean {
+      return nameOf(type, registry) === typeName(this.typeUrl);
+   }
+
+   static create(): Any {
+      return new Any();
+   }
+
+   static encode(v: Any, w: Writer = new Writer()): Writer {
+      if (v.typeUrl !== "") {
+         w.tag(1, WireType.Bytes).string(v.typeUrl);
+      }
+      if (v.value.length > 0) {
+         w.tag(2, WireType.Bytes).bytes(v.value);
+      }
+      return w;
+   }
+
+   static decode(input: Reader | Uint8Array, length?: number): Any {
+      const r = Reader.create(input);
+      const end = length === undefined ? r.len : r.pos + length;
+      const v = new Any();
+      while (r.pos < end) {
+         const t = r.uint32();
+         switch (t >>> 3) {
+            case 1:
+               v.typeUrl = r.string();
+               break;
+            case 2:
+               v.value = r.bytes();
+               break;
+            default:
+               r.skip(t & 7, t >>> 3);
+         }
+      }
+      return v;
+   }
+
+   /**
+    * Returns the packed message in JSON, with its type URL as the "@type"
+    * member. Its type must be registered.
+    */
+   static toJSON(v: Any, registry = defaultRegistry): JSONValue {
+      if (v.typeUrl === "") {
+         return {};
+      }
+      const name = typeName(v.typeUrl);
+      const type = resolve(name, registry);
+      const j = toJSON(type, type.decode(v.value));
+      if (name in wellKnownTypes()) {
+         return { "@type": v.typeUrl, value: j };
+      }
+      return { "@type": v.typeUrl, ...(j as JSONObject) };
+   }
+
+   toJSON(): JSONValue {
+      return Any.toJSON(this);
+   }
+
+   /**
+    * Reads a message from JSON with an "@type" member, and packs it. Its type
+    * must be in the registry of the options, or the default registry.
+    */
+   static fromJSON(j: JSONValue, options?: JSONReadOptions): Any {
+      const o = json.object(j, "google.protobuf.Any");
+      const { "@type": typeUrl, ...rest } = o;
+      if (typeUrl === undefined && Object.keys(rest).length === 0) {
+         return new Any();
+      }
+      if (typeof typeUrl !== "string") {
+         throw new Error(`invalid JSON value for google.protobuf.Any: ${JSON.stringify(j)}`);
+      }
+      const name = typeName(typeUrl);
+      const type = resolve(name, options?.registry ?? defaultRegistry);
+      const m = name in wellKnownTypes() ? type.fromJSON(rest.value ?? null, options) : type.fromJSON(rest, options);
+      return new Any(typeUrl, encode(type, m));
+   }
+}
@@ -0,0 +1,45 @@
+/** Base64 encoding of bytes, as used by the proto3 JSON mapping. */
+
+const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+
+// Both the standard and the URL-safe alphabet are accepted when decoding.
+const values: { [c: string]: number } = {};
+for (let i = 0; i < alphabet.length; i++) {
+   values[alphabet[i]] = i;
+}
+values["-"] = 62;
+values["_"] = 63;
+
+/** Encodes bytes with the standard, padded alphabet. */
+export function encode(bytes: Uint8Array): string {
+   let s = "";
+   for (let i = 0; i < bytes.length; i += 3) {
+      const n = (bytes[i] << 16) | ((bytes[i + 1] ?? 0) << 8) | (bytes[i + 2] ?? 0);
+      s += alphabet[(n >> 18) & 63] + alphabet[(n >> 12) & 63];
+      s += i + 1 < bytes.length ? alphabet[(n >> 6) & 63] : "=";
+      s += i + 2 < bytes.length ? alphabet[n & 63] : "=";
+   }
+   return s;
+}
+
+/** Decodes standard or URL-safe base64, with or without padding. */
+export function decode(s: string): Uint8Array {
+   s = s.replace(/=+$/, "");
+   const out = new Uint8Array(Math.floor((s.length * 3) / 4));
+   let bits = 0;
+   let n = 0;
+   let pos = 0;
+   for (const c of s) {
+      const v = values[c];
+      if (v === undefined) {
+         throw new Error(`invalid base64 character ${JSON.stringify(c)}`);
+      }
+      n = (n << 6) | v;
+      bits += 6;
+      if (bits >= 8) {
+         bits -= 8;
+         out[pos++] = (n >> bits) & 255;
+      }
+   }
+   return out.subarray(0, pos);
+}
@@ -0,0 +1,13 @@
+const warned = new Set<string>();
+
+/**
+ * Logs a warning the first time a deprecated field, named by its full proto
+ * name, is set. Generated code calls it when the deprecated=warn parameter is
+ * given.
+ */
+export function deprecated(name: string): void {
+   if (!warned.has(name)) {
+      warned.add(name);
+      console.warn(`${name} is deprecated`);
+   }
+}
@@ -0,0 +1,10 @@
+/** RequiredFieldError reports the required fields missing from a decoded message. */
+export class RequiredFieldError extends Error {
+   constructor(
+      /** Full path of each missing field, such as "Request.key". */
+      readonly fields: string[]
+   ) {
+      super(`missing required fields: ${fields.join(", ")}`);
+      this.name = "RequiredFieldError";
+   }
+}
@@ -0,0 +1,116 @@
+/**
+ * Extensions of proto2 messages. An extendable message keeps the fields in its
+ * extension ranges encoded, by field number, so that they survive decoding and
+ * encoding whether or not the extensions are known. Generated extension
+ * descriptors read and write them.
+ */
+import { Reader } from "./reader";
+import { Writer } from "./writer";
+
+/** The encoded extension fields of a message, with their tags, by field number. */
+export type ExtensionFields = Map<number, Uint8Array[]>;
+
+/** Extendee is the name of an extendable message and its extension ranges. */
+export interface Extendee {
+   readonly name: string;
+   /** Ranges of field numbers, from the start to before the end. */
+   readonly ranges: readonly (readonly [number, number])[];
+}
+
+/** Extension is the generated descriptor of an extension with values of type T. */
+export interface Extension<T> {
+   /** The fully-qualified proto name, such as "pkg.Message.ext". */
+   readonly name: string;
+   /** The fully-qualified proto name of the extended message. */
+   readonly extendee: string;
+   readonly fieldNo: number;
+   /** Returns the value of the extension while it is unset. */
+   defaultValue(): T;
+   /** Writes the value, preceded by its tags. */
+   encode(v: T, w: Writer): void;
+   /** Reads an occurrence of the field, whose tag t was read, into the value so far. */
+   decode(r: Reader, t: number, v?: T): T;
+}
+
+/** Checks that the extension extends the message, in one of its ranges. */
+function check(ext: Extension<unknown>, extendee: Extendee): void {
+   if (ext.extendee !== extendee.name) {
+      throw new Error(`extension ${ext.name} extends ${ext.extendee}, not ${extendee.name}`);
+   }
+   if (!inRanges(extendee, ext.fieldNo)) {
+      throw new Error(`extension ${ext.name} has field number ${ext.fieldNo} outside the extension ranges of ${extendee.name}`);
+   }
+}
+
+/** Returns whether the field number is in the extension ranges of the message. */
+export function inRanges(extendee: Extendee, fieldNo: number): boolean {
+   return extendee.ranges.some(([start, end]) => fieldNo >= start && fieldNo < end);
+}
+
+/** Returns the value of the extension, or its default if it is unset. */
+export function get<T>(fields: ExtensionFields | undefined, ext: Extension<T>, extendee: Extendee): T {
+   check(ext, extendee);
+   const records = fields?.get(ext.fieldNo);
+   if (records === undefined) {
+      return ext.defaultValue();
+   }
+   let v: T | undefined;
+   for (const data of records) {
+      const r = new Reader(data);
+      while (r.pos < r.len) {
+         v = ext.decode(r, r.uint32(), v);
+      }
+   }
+   return v as T;
+}
+
+/** Returns whether the extension is set. */
+export function has(fields: ExtensionFields | undefined, ext: Extension<unknown>, extendee: Extendee): boolean {
+   check(ext, extendee);
+   return fields?.has(ext.fieldNo) ?? false;
+}
+
+/**
+ * Sets the extension, and returns the fields holding it. Setting a message
+ * extension to undefined clears it.
+ */
+export function set<T>(fields: ExtensionFields | undefined, ext: Extension<T>, extendee: Extendee, v: T): ExtensionFields {
+   check(ext, extendee);
+   fields ??= new Map();
+   const w = new Writer();
+   ext.encode(v, w);
+   const data = w.finish();
+   if (data.length > 0) {
+      fields.set(ext.fieldNo, [data]);
+   } else {
+      fields.delete(ext.fieldNo);
+   }
+   return fields;
+}
+
+/** Clears the extension. */
+export function clear(fields: ExtensionFields | undefined, ext: Extension<unknown>, extendee: Extendee): void {
+   check(ext, extendee);
+   fields?.delete(ext.fieldNo);
+}
+
+/** Adds an encoded field, as read by decoding, and returns the fields. */
+export function add(fields: ExtensionFields | undefined, fieldNo: number, data: Uint8Array): ExtensionFields {
+   fields ??= new Map();
+   const records = fields.get(fieldNo);
+   if (records === undefined) {
+      fields.set(fieldNo, [data]);
+   } else {
+      records.push(data);
+   }
+   return fields;
+}
+
+/** Writes the encoded fields. */
+export function write(fields: ExtensionFields, w: Writer): void {
+   for (const records of fields.values()) {
+      for (const data of records) {
+         w.raw(data);
+      }
+   }
+}
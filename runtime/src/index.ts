@@ -0,0 +1,27 @@
+export { Any } from "./any";
+export * as base64 from "./base64";
+export { deprecated } from "./deprecated";
+export { RequiredFieldError } from "./errors";
+export * as extension from "./extension";
+export type { Extendee, Extension, ExtensionFields } from "./extension";
+export * as json from "./json";
+export type { JSONObject, JSONReadOptions, JSONValue } from "./json";
+export * as Long from "./long";
+export { Reader } from "./reader";
+export type { MessageType } from "./registry";
+export { Registry, registry } from "./registry";
+export type { Transport } from "./rpc";
+export type { Violation } from "./validate";
+export { Method } from "./rpc";
+export { WireType } from "./wire";
+export { WebSocketTransport } from "./wsrpc";
+export { Writer } from "./writer";
+export * as wkt from "./wkt";
+export type { Duration } from "./wkt";
+
+/**
+ * Generated files reference this constant, so that compiling them against an
+ * incompatible version of the runtime fails. It is renamed whenever generated
+ * code starts to depend on a change to the runtime.
+ */
+export const packageIsVersion1 = true;
@@ -0,0 +1,184 @@
+/**
+ * Conversions between field values and the proto3 JSON format, used by the
+ * generated toJSON and fromJSON methods.
+ */
+import * as base64 from "./base64";
+import type { Registry } from "./registry";
+
+export type JSONValue = null | boolean | number | string | JSONValue[] | JSONObject;
+export type JSONObject = { [key: string]: JSONValue };
+
+/** Options of the generated fromJSON methods. */
+export interface JSONReadOptions {
+   /**
+    * Skip members that do not name a field of the message. By default they
+    * are rejected, as the proto3 JSON mapping recommends.
+    */
+   ignoreUnknownFields?: boolean;
+   /**
+    * The registry of the types of google.protobuf.Any messages, if not the
+    * one generated files add their messages to.
+    */
+   registry?: Registry;
+}
+
+/** Returns the error reported for a value that does not fit the field. */
+function invalid(type: string, json: JSONValue): Error {
+   return new Error(`invalid JSON value for ${type}: ${JSON.stringify(json)}`);
+}
+
+/** Writes a float or double, which may be NaN or infinite. */
+export function writeFloat(v: number): number | string {
+   if (Number.isNaN(v)) {
+      return "NaN";
+   }
+   if (v === Infinity) {
+      return "Infinity";
+   }
+   if (v === -Infinity) {
+      return "-Infinity";
+   }
+   return v;
+}
+
+/** Writes a 64-bit integer, in any of its representations, as a decimal string. */
+export function writeInt64(v: bigint | number | string): string {
+   return String(v);
+}
+
+export function writeBytes(v: Uint8Array): string {
+   return base64.encode(v);
+}
+
+/** Writes an enum value by name, or by number if the name is unknown. */
+export function writeEnum(v: number, names: { [value: number]: string }): string | number {
+   return names[v] ?? v;
+}
+
+/** Checks that the value is a JSON object, naming the message it encodes. */
+export function object(json: JSONValue, type: string): JSONObject {
+   if (typeof json !== "object" || json === null || Array.isArray(json)) {
+      throw invalid(type, json);
+   }
+   return json;
+}
+
+export function array(json: JSONValue, type: string): JSONValue[] {
+   if (!Array.isArray(json)) {
+      throw invalid(type, json);
+   }
+   return json;
+}
+
+/** Reports a member that does not name a field, unless options allow it. */
+export function unknownField(type: string, key: string, options?: JSONReadOptions): void {
+   if (!options?.ignoreUnknownFields) {
+      throw new Error(`unknown field ${JSON.stringify(key)} in JSON for ${type}`);
+   }
+}
+
+export function bool(json: JSONValue): boolean {
+   if (typeof json !== "boolean") {
+      throw invalid("bool", json);
+   }
+   return json;
+}
+
+/** Reads a bool map key, which JSON writes as a string. */
+export function boolKey(key: string): boolean {
+   switch (key) {
+      case "true":
+         return true;
+      case "false":
+         return false;
+   }
+   throw invalid("bool", key);
+}
+
+export function string(json: JSONValue): string {
+   if (typeof json !== "string") {
+      throw invalid("string", json);
+   }
+   return json;
+}
+
+export function bytes(json: JSONValue): Uint8Array {
+   if (typeof json !== "string") {
+      throw invalid("bytes", json);
+   }
+   return base64.decode(json);
+}
+
+/** Reads a float or double from a number or a string. */
+export function float(json: JSONValue): number {
+   switch (json) {
+      case "NaN":
+         return NaN;
+      case "Infinity":
+         return Infinity;
+      case "-Infinity":
+         return -Infinity;
+   }
+   const v = typeof json === "string" && json.trim() !== "" ? Number(json) : json;
+   if (typeof v !== "number" || Number.isNaN(v)) {
+      throw invalid("float", json);
+   }
+   return v;
+}
+
+/** Reads an integer from a number or a decimal string, and checks its range. */
+function integer(json: JSONValue, type: string, min: number, max: number): number {
+   const v = typeof json === "string" && json.trim() !== "" ? Number(json) : json;
+   if (typeof v !== "number" || !Number.isInteger(v) || v < min || v > max) {
+      throw invalid(type, json);
+   }
+   return v;
+}
+
+export function int32(json: JSONValue): number {
+   return integer(json, "int32", -0x80000000, 0x7fffffff);
+}
+
+export function uint32(json: JSONValue): number {
+   return integer(json, "uint32", 0, 0xffffffff);
+}
+
+/** Reads a 64-bit integer from a decimal string or a number. */
+function integer64(json: JSONValue, type: string, bits: (v: bigint) => bigint): bigint {
+   let v: bigint | undefined;
+   if (typeof json === "number" && Number.isInteger(json)) {
+      v = BigInt(json);
+   } else if (typeof json === "string" && /^-?\d+$/.test(json)) {
+      v = BigInt(json);
+   }
+   if (v === undefined || bits(v) !== v) {
+      throw invalid(type, json);
+   }
+   return v;
+}
+
+export function int64(json: JSONValue): bigint {
+   return integer64(json, "int64", (v) => BigInt.asIntN(64, v));
+}
+
+export function uint64(json: JSONValue): bigint {
+   return integer64(json, "uint64", (v) => BigInt.asUintN(64, v));
+}
+
+/**
+ * Reads an enum value from its name or its number. An unknown name is
+ * rejected, or read as undefined if options say to ignore unknown fields, so
+ * that the field is left unset.
+ */
+export function enumValue<E extends number>(json: JSONValue, values: { [name: string]: E }, type: string, options?: JSONReadOptions): E | undefined {
+   if (typeof json === "string") {
+      if (!Object.prototype.hasOwnProperty.call(values, json)) {
+         if (options?.ignoreUnknownFields) {
+            return undefined;
+         }
+         throw invalid(type, json);
+      }
+      return values[json];
+   }
+   return int32(json) as E;
+}
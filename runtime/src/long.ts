@@ -0,0 +1,33 @@
+/**
+ * Helpers for 64-bit integers. The Reader and Writer represent them as bigint;
+ * generated code converts to and from the representation chosen for fields.
+ */
+
+const minSafe = BigInt(Number.MIN_SAFE_INTEGER);
+const maxSafe = BigInt(Number.MAX_SAFE_INTEGER);
+
+/** Converts a number, decimal string or bigint to a bigint. */
+export function toBigInt(v: bigint | number | string): bigint {
+   if (typeof v === "number" && !Number.isInteger(v)) {
+      throw new RangeError(`${v} is not an integer`);
+   }
+   return BigInt(v);
+}
+
+/** Converts a bigint to a number, failing if precision would be lost. */
+export function toNumber(v: bigint): number {
+   if (v < minSafe || v > maxSafe) {
+      throw new RangeError(`${v} is outside the safe integer range`);
+   }
+   return Number(v);
+}
+
+/** Reports whether the value fits in a signed 64-bit integer. */
+export function isInt64(v: bigint): boolean {
+   return BigInt.asIntN(64, v) === v;
+}
+
+/** Reports whether the value fits in an unsigned 64-bit integer. */
+export function isUint64(v: bigint): boolean {
+   return BigInt.asUintN(64, v) === v;
+}
@@ -0,0 +1,187 @@
+import { WireType } from "./wire";
+
+const decoder = new TextDecoder("utf-8", { fatal: true });
+
+/** Reader decodes values in the protocol buffer binary format. */
+export class Reader {
+   /** Offset of the next byte to read. */
+   pos = 0;
+   /** Length of the buffer. */
+   readonly len: number;
+   private readonly view: DataView;
+
+   constructor(readonly buf: Uint8Array) {
+      this.len = buf.length;
+      this.view = new DataView(buf.buffer, buf.byteOffset, buf.byteLength);
+   }
+
+   /** Returns a Reader for the bytes, or the Reader itself. */
+   static create(input: Reader | Uint8Array): Reader {
+      return input instanceof Reader ? input : new Reader(input);
+   }
+
+   /** Reads a varint, keeping only its low 32 bits. */
+   uint32(): number {
+      let v = 0;
+      for (let shift = 0; shift < 70; shift += 7) {
+         const b = this.byte();
+         if (shift < 32) {
+            v |= (b & 127) << shift;
+         }
+         if (b < 128) {
+            return v >>> 0;
+         }
+      }
+      throw new Error(`invalid varint at offset ${this.pos}`);
+   }
+
+   int32(): number {
+      return this.uint32() | 0;
+   }
+
+   sint32(): number {
+      const n = this.uint32();
+      return (n >>> 1) ^ -(n & 1);
+   }
+
+   uint64(): bigint {
+      return this.varint64();
+   }
+
+   int64(): bigint {
+      return BigInt.asIntN(64, this.varint64());
+   }
+
+   sint64(): bigint {
+      const n = this.varint64();
+      return (n >> 1n) ^ -(n & 1n);
+   }
+
+   bool(): boolean {
+      return this.varint64() !== 0n;
+   }
+
+   fixed32(): number {
+      return this.view.getUint32(this.advance(4), true);
+   }
+
+   sfixed32(): number {
+      return this.view.getInt32(this.advance(4), true);
+   }
+
+   fixed64(): bigint {
+      return this.view.getBigUint64(this.advance(8), true);
+   }
+
+   sfixed64(): bigint {
+      return this.view.getBigInt64(this.advance(8), true);
+   }
+
+   float(): number {
+      return this.view.getFloat32(this.advance(4), true);
+   }
+
+   double(): number {
+      return this.view.getFloat64(this.advance(8), true);
+   }
+
+   /** Reads length-prefixed bytes. */
+   bytes(): Uint8Array {
+      const n = this.uint32();
+      const start = this.advance(n);
+      return this.buf.slice(start, start + n);
+   }
+
+   /** Reads a length-prefixed UTF-8 string. */
+   string(): string {
+      return decoder.decode(this.bytes());
+   }
+
+   /**
+    * Skips the value of a field with the given wire type. The field number,
+    * if given, is checked against the END_GROUP tag of a group.
+    */
+   skip(wireType: number, fieldNo?: number): this {
+      switch (wireType) {
+         case WireType.Varint:
+            this.varint64();
+            break;
+         case WireType.Fixed64:
+            this.advance(8);
+            break;
+         case WireType.Bytes:
+            this.advance(this.uint32());
+            break;
+         case WireType.StartGroup:
+            this.groupEnd(fieldNo);
+            break;
+         case WireType.Fixed32:
+            this.advance(4);
+            break;
+         default:
+            throw new Error(`invalid wire type ${wireType} at offset ${this.pos}`);
+      }
+      return this;
+   }
+
+   /**
+    * Reads a group of the field, whose START_GROUP tag was read, with the
+    * function given the length of its fields, and then its END_GROUP tag.
+    */
+   group<T>(fieldNo: number, read: (length: number) => T): T {
+      const start = this.pos;
+      const end = this.groupEnd(fieldNo);
+      const after = this.pos;
+      this.pos = start;
+      const v = read(end - start);
+      this.pos = after;
+      return v;
+   }
+
+   /**
+    * Skips the fields of a group up to its END_GROUP tag, which must be of the
+    * field if given, and returns the offset of the tag.
+    */
+   private groupEnd(fieldNo?: number): number {
+      for (;;) {
+         const end = this.pos;
+         const t = this.uint32();
+         if ((t & 7) === WireType.EndGroup) {
+            if (fieldNo !== undefined && t >>> 3 !== fieldNo) {
+               throw new Error(`END_GROUP tag of field ${t >>> 3} at offset ${end} ends a group of field ${fieldNo}`);
+            }
+            return end;
+         }
+         this.skip(t & 7, t >>> 3);
+      }
+   }
+
+   private varint64(): bigint {
+      let v = 0n;
+      for (let shift = 0n; shift < 70n; shift += 7n) {
+         const b = this.byte();
+         v |= BigInt(b & 127) << shift;
+         if (b < 128) {
+            return BigInt.asUintN(64, v);
+         }
+      }
+      throw new Error(`invalid varint at offset ${this.pos}`);
+   }
+
+   private byte(): number {
+      if (this.pos >= this.len) {
+         throw new RangeError(`index out of range: ${this.pos} + 1 > ${this.len}`);
+      }
+      return this.buf[this.pos++];
+   }
+
+   /** Moves past n bytes, returning the offset of the first. */
+   private advance(n: number): number {
+      if (this.pos + n > this.len) {
+         throw new RangeError(`index out of range: ${this.pos} + ${n} > ${this.len}`);
+      }
+      const start = this.pos;
+      this.pos += n;
+      return start;
+   }
+}
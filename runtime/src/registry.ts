@@ -0,0 +1,48 @@
+import type { JSONReadOptions, JSONValue } from "./json";
+import { Reader } from "./reader";
+import type { Writer } from "./writer";
+
+/**
+ * MessageType is the static side of a generated message class, or the
+ * namespace of a generated message interface. Only the latter has encode and
+ * toJSON, which classes have as instance methods.
+ */
+export interface MessageType<T = unknown> {
+   decode(input: Reader | Uint8Array, length?: number): T;
+   fromJSON(json: JSONValue, options?: JSONReadOptions): T;
+   encode?(m: T, w?: Writer): Writer;
+   toJSON?(m: T): JSONValue;
+}
+
+/**
+ * Registry maps fully-qualified proto names to generated message types. Type
+ * URLs, such as those of google.protobuf.Any, are looked up by their last
+ * segment.
+ */
+export class Registry {
+   private readonly types = new Map<string, MessageType>();
+   private readonly names = new Map<MessageType, string>();
+
+   /**
+    * Adds a message type. A module that runs again, after a hot reload or as
+    * a second copy in a bundle, registers its names again: the last type
+    * registered under a name wins, while earlier ones keep their name.
+    */
+   register(name: string, type: MessageType): void {
+      this.types.set(name, type);
+      this.names.set(type, name);
+   }
+
+   /** Returns the type registered under the name or type URL, if any. */
+   lookup(name: string): MessageType | undefined {
+      return this.types.get(name.slice(name.lastIndexOf("/") + 1));
+   }
+
+   /** Returns the name the type is registered under, if any. */
+   nameOf(type: MessageType): string | undefined {
+      return this.names.get(type);
+   }
+}
+
+/** The registry that generated files add their messages to. */
+export const registry = new Registry();
@@ -0,0 +1,31 @@
+/** Method describes an RPC method of a service and how to encode its messages. */
+export class Method<I, O> {
+   constructor(
+      /** Fully-qualified name of the service, such as "grpc.testing.Test". */
+      readonly service: string,
+      /** Name of the method as declared in the service. */
+      readonly name: string,
+      /** Position of the method in its service, starting at 1. */
+      readonly id: number,
+      readonly clientStreaming: boolean,
+      readonly serverStreaming: boolean,
+      readonly encode: (request: I) => Uint8Array,
+      readonly decode: (bytes: Uint8Array) => O
+   ) {}
+
+   /** The HTTP/2 path of the method, as used by gRPC. */
+   get path(): string {
+      return `/${this.service}/${this.name}`;
+   }
+}
+
+/**
+ * Transport carries the calls of generated service clients. There is one
+ * method for each combination of client and server streaming.
+ */
+export interface Transport {
+   unary<I, O>(method: Method<I, O>, request: I): Promise<O>;
+   serverStream<I, O>(method: Method<I, O>, request: I): AsyncIterable<O>;
+   clientStream<I, O>(method: Method<I, O>, requests: AsyncIterable<I>): Promise<O>;
+   bidiStream<I, O>(method: Method<I, O>, requests: AsyncIterable<I>): AsyncIterable<O>;
+}
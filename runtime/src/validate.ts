@@ -0,0 +1,9 @@
+/** Violation describes a field value that breaks a constraint declared in the schema. */
+export interface Violation {
+   /** Full path of the field, such as "Request.key" or "Request.items[2]". */
+   field: string;
+   /** The broken rule, such as "string.min_len". */
+   rule: string;
+   /** Description of the constraint, such as "must be at least 3 characters long". */
+   message: string;
+}
@@ -0,0 +1,9 @@
+/** Wire types defined by the protocol buffer binary format. */
+export enum WireType {
+   Varint = 0,
+   Fixed64 = 1,
+   Bytes = 2,
+   StartGroup = 3,
+   EndGroup = 4,
+   Fixed32 = 5,
+}
@@ -0,0 +1,299 @@
+/**
+ * Codecs of the well-known types that generated code represents with native
+ * types: Timestamp as a Date, Duration as a Duration, the wrappers as their
+ * primitive and Struct, Value and ListValue as JSON values.
+ */
+import * as json from "./json";
+import type { JSONObject, JSONReadOptions, JSONValue } from "./json";
+import { Reader } from "./reader";
+import { WireType } from "./wire";
+import { Writer } from "./writer";
+
+/** WellKnownType converts a native value to and from a well-known message. */
+export interface WellKnownType<T> {
+   /** Returns the value of the message with no field set. */
+   create(): T;
+   encode(v: T, w?: Writer): Writer;
+   decode(input: Reader | Uint8Array, length?: number): T;
+   toJSON(v: T): JSONValue;
+   fromJSON(json: JSONValue, options?: JSONReadOptions): T;
+}
+
+/** Duration is a signed span of time. Seconds and nanos have the same sign. */
+export interface Duration {
+   seconds: number;
+   nanos: number;
+}
+
+/** Calls field for each field of the message, which skips the unknown ones. */
+function decodeFields(input: Reader | Uint8Array, length: number | undefined, field: (r: Reader, no: number, t: number) => void): void {
+   const r = Reader.create(input);
+   const end = length === undefined ? r.len : r.pos + length;
+   while (r.pos < end) {
+      const t = r.uint32();
+      field(r, t >>> 3, t & 7);
+   }
+}
+
+/** Returns the codec of a wrapper, whose value field is omitted when zero. */
+function wrapper<T>(
+   wireType: WireType,
+   write: (w: Writer, v: T) => Writer,
+   read: (r: Reader) => T,
+   zero: () => T,
+   toJSON: (v: T) => JSONValue,
+   fromJSON: (json: JSONValue) => T
+): WellKnownType<T> {
+   const z = zero();
+   return {
+      create: zero,
+      encode(v: T, w: Writer = new Writer()): Writer {
+         if (v !== z && !(v instanceof Uint8Array && v.length === 0)) {
+            write(w.tag(1, wireType), v);
+         }
+         return w;
+      },
+      decode(input: Reader | Uint8Array, length?: number): T {
+         let v = zero();
+         decodeFields(input, length, (r, no, t) => {
+            if (no === 1) {
+               v = read(r);
+            } else {
+               r.skip(t, no);
+            }
+         });
+         return v;
+      },
+      toJSON,
+      fromJSON,
+   };
+}
+
+export const DoubleValue = wrapper<number>(WireType.Fixed64, (w, v) => w.double(v), (r) => r.double(), () => 0, json.writeFloat, json.float);
+export const FloatValue = wrapper<number>(WireType.Fixed32, (w, v) => w.float(v), (r) => r.float(), () => 0, json.writeFloat, json.float);
+export const Int64Value = wrapper<bigint>(WireType.Varint, (w, v) => w.int64(v), (r) => r.int64(), () => 0n, json.writeInt64, json.int64);
+export const UInt64Value = wrapper<bigint>(WireType.Varint, (w, v) => w.uint64(v), (r) => r.uint64(), () => 0n, json.writeInt64, json.uint64);
+export const Int32Value = wrapper<number>(WireType.Varint, (w, v) => w.int32(v), (r) => r.int32(), () => 0, (v) => v, json.int32);
+export const UInt32Value = wrapper<number>(WireType.Varint, (w, v) => w.uint32(v), (r) => r.uint32(), () => 0, (v) => v, json.uint32);
+export const BoolValue = wrapper<boolean>(WireType.Varint, (w, v) => w.bool(v), (r) => r.bool(), () => false, (v) => v, json.bool);
+export const StringValue = wrapper<string>(WireType.Bytes, (w, v) => w.string(v), (r) => r.string(), () => "", (v) => v, json.string);
+export const BytesValue = wrapper<Uint8Array>(WireType.Bytes, (w, v) => w.bytes(v), (r) => r.bytes(), () => new Uint8Array(0), json.writeBytes, json.bytes);
+
+const timestampPattern = /^(\d{4})-(\d{2})-(\d{2})T(\d{2}):(\d{2}):(\d{2})(?:\.(\d{1,9}))?(?:Z|([+-])(\d{2}):(\d{2}))$/i;
+
+/** google.protobuf.Timestamp as a Date, which keeps milliseconds only. */
+export const Timestamp: WellKnownType<Date> = {
+   create: () => new Date(0),
+   encode(v: Date, w: Writer = new Writer()): Writer {
+      const ms = v.getTime();
+      const seconds = Math.floor(ms / 1000);
+      const nanos = (ms - seconds * 1000) * 1000000;
+      if (seconds !== 0) {
+         w.tag(1, WireType.Varint).int64(BigInt(seconds));
+      }
+      if (nanos !== 0) {
+         w.tag(2, WireType.Varint).int32(nanos);
+      }
+      return w;
+   },
+   decode(input: Reader | Uint8Array, length?: number): Date {
+      let seconds = 0;
+      let nanos = 0;
+      decodeFields(input, length, (r, no, t) => {
+         if (no === 1) {
+            seconds = Number(r.int64());
+         } else if (no === 2) {
+            nanos = r.int32();
+         } else {
+            r.skip(t, no);
+         }
+      });
+      return new Date(seconds * 1000 + Math.floor(nanos / 1000000));
+   },
+   /** Writes the time in RFC 3339 format in UTC, such as "1972-01-01T10:00:20.021Z". */
+   toJSON(v: Date): JSONValue {
+      return v.toISOString();
+   },
+   fromJSON(j: JSONValue): Date {
+      const m = typeof j === "string" ? timestampPattern.exec(j) : null;
+      if (!m) {
+         throw new Error(`invalid JSON value for google.protobuf.Timestamp: ${JSON.stringify(j)}`);
+      }
+      const [, year, month, day, hour, minute, second, fraction = "", sign, offsetHours, offsetMinutes] = m;
+      let ms = Date.UTC(+year, +month - 1, +day, +hour, +minute, +second, +fraction.padEnd(3, "0").slice(0, 3));
+      if (sign) {
+         const offset = (+offsetHours * 60 + +offsetMinutes) * 60000;
+         ms += sign === "+" ? -offset : offset;
+      }
+      return new Date(ms);
+   },
+};
+
+const durationPattern = /^(-)?(\d+)(?:\.(\d{1,9}))?s$/;
+
+/** google.protobuf.Duration. */
+export const Duration: WellKnownType<Duration> = {
+   create: () => ({ seconds: 0, nanos: 0 }),
+   encode(v: Duration, w: Writer = new Writer()): Writer {
+      if (v.seconds !== 0) {
+         w.tag(1, WireType.Varint).int64(BigInt(v.seconds));
+      }
+      if (v.nanos !== 0) {
+         w.tag(2, WireType.Varint).int32(v.nanos);
+      }
+      return w;
+   },
+   decode(input: Reader | Uint8Array, length?: number): Duration {
+      const v = Duration.create();
+      decodeFields(input, length, (r, no, t) => {
+         if (no === 1) {
+            v.seconds = Number(r.int64());
+         } else if (no === 2) {
+            v.nanos = r.int32();
+         } else {
+            r.skip(t, no);
+         }
+      });
+      return v;
+   },
+   /** Writes the duration in seconds, such as "1.5s", with 0, 3, 6 or 9 fractional digits. */
+   toJSON(v: Duration): JSONValue {
+      const sign = v.seconds < 0 || v.nanos < 0 ? "-" : "";
+      let s = sign + Math.abs(v.seconds);
+      if (v.nanos !== 0) {
+         let fraction = String(Math.abs(v.nanos)).padStart(9, "0");
+         while (fraction.endsWith("000")) {
+            fraction = fraction.slice(0, -3);
+         }
+         s += "." + fraction;
+      }
+      return s + "s";
+   },
+   fromJSON(j: JSONValue): Duration {
+      const m = typeof j === "string" ? durationPattern.exec(j) : null;
+      if (!m) {
+         throw new Error(`invalid JSON value for google.protobuf.Duration: ${JSON.stringify(j)}`);
+      }
+      const [, sign, seconds, fraction = ""] = m;
+      const v = { seconds: +seconds, nanos: +fraction.padEnd(9, "0") };
+      if (sign) {
+         v.seconds = -v.seconds;
+         v.nanos = -v.nanos;
+      }
+      return v;
+   },
+};
+
+/** google.protobuf.Value as any JSON value. */
+export const Value: WellKnownType<JSONValue> = {
+   create: () => null,
+   encode(v: JSONValue, w: Writer = new Writer()): Writer {
+      if (v === null) {
+         w.tag(1, WireType.Varint).int32(0);
+      } else if (typeof v === "number") {
+         w.tag(2, WireType.Fixed64).double(v);
+      } else if (typeof v === "string") {
+         w.tag(3, WireType.Bytes).string(v);
+      } else if (typeof v === "boolean") {
+         w.tag(4, WireType.Varint).bool(v);
+      } else if (Array.isArray(v)) {
+         ListValue.encode(v, w.tag(6, WireType.Bytes).fork()).ldelim();
+      } else {
+         Struct.encode(v, w.tag(5, WireType.Bytes).fork()).ldelim();
+      }
+      return w;
+   },
+   decode(input: Reader | Uint8Array, length?: number): JSONValue {
+      let v: JSONValue = null;
+      decodeFields(input, length, (r, no, t) => {
+         switch (no) {
+            case 1:
+               r.int32();
+               v = null;
+               break;
+            case 2:
+               v = r.double();
+               break;
+            case 3:
+               v = r.string();
+               break;
+            case 4:
+               v = r.bool();
+               break;
+            case 5:
+               v = Struct.decode(r, r.uint32());
+               break;
+            case 6:
+               v = ListValue.decode(r, r.uint32());
+               break;
+            default:
+               r.skip(t, no);
+         }
+      });
+      return v;
+   },
+   toJSON: (v) => v,
+   fromJSON: (j) => j,
+};
+
+/** google.protobuf.Struct as a JSON object. */
+export const Struct: WellKnownType<JSONObject> = {
+   create: () => ({}),
+   encode(v: JSONObject, w: Writer = new Writer()): Writer {
+      for (const [k, e] of Object.entries(v)) {
+         w.tag(1, WireType.Bytes).fork();
+         w.tag(1, WireType.Bytes).string(k);
+         Value.encode(e, w.tag(2, WireType.Bytes).fork()).ldelim();
+         w.ldelim();
+      }
+      return w;
+   },
+   decode(input: Reader | Uint8Array, length?: number): JSONObject {
+      const v: JSONObject = {};
+      decodeFields(input, length, (r, no, t) => {
+         if (no !== 1) {
+            r.skip(t, no);
+            return;
+         }
+         let key = "";
+         let value: JSONValue = null;
+         decodeFields(r, r.uint32(), (r, no, t) => {
+            if (no === 1) {
+               key = r.string();
+            } else if (no === 2) {
+               value = Value.decode(r, r.uint32());
+            } else {
+               r.skip(t, no);
+            }
+         });
+         v[key] = value;
+      });
+      return v;
+   },
+   toJSON: (v) => v,
+   fromJSON: (j) => json.object(j, "google.protobuf.Struct"),
+};
+
+/** google.protobuf.ListValue as a JSON array. */
+export const ListValue: WellKnownType<JSONValue[]> = {
+   create: () => [],
+   encode(v: JSONValue[], w: Writer = new Writer()): Writer {
+      for (const e of v) {
+         Value.encode(e, w.tag(1, WireType.Bytes).fork()).ldelim();
+      }
+      return w;
+   },
+   decode(input: Reader | Uint8Array, length?: number): JSONValue[] {
+      const v: JSONValue[] = [];
+      decodeFields(input, length, (r, no, t) => {
+         if (no === 1) {
+            v.push(Value.decode(r, r.uint32()));
+         } else {
+            r.skip(t, no);
+         }
+      });
+      return v;
+   },
+   toJSON: (v) => v,
+   fromJSON: (j) => json.array(j, "google.protobuf.ListValue"),
+};
@@ -0,0 +1,168 @@
+import { WireType } from "./wire";
+
+const encoder = new TextEncoder();
+
+/**
+ * Writer encodes values in the protocol buffer binary format. Length-delimited
+ * values of unknown size, such as embedded messages, are written between
+ * `fork()` and `ldelim()`.
+ */
+export class Writer {
+   private buf = new Uint8Array(64);
+   private view = new DataView(this.buf.buffer);
+   private pos = 0;
+   private forks: number[] = [];
+
+   /** Writes the key of a field: its number and wire type. */
+   tag(fieldNo: number, wireType: WireType): this {
+      return this.uint32(((fieldNo << 3) | wireType) >>> 0);
+   }
+
+   uint32(v: number): this {
+      v >>>= 0;
+      this.reserve(5);
+      while (v > 127) {
+         this.buf[this.pos++] = (v & 127) | 128;
+         v >>>= 7;
+      }
+      this.buf[this.pos++] = v;
+      return this;
+   }
+
+   /** Negative values are sign-extended to ten bytes, as in every other runtime. */
+   int32(v: number): this {
+      return v < 0 ? this.varint64(BigInt.asUintN(64, BigInt(v))) : this.uint32(v);
+   }
+
+   sint32(v: number): this {
+      return this.uint32((v << 1) ^ (v >> 31));
+   }
+
+   uint64(v: bigint): this {
+      return this.varint64(BigInt.asUintN(64, v));
+   }
+
+   int64(v: bigint): this {
+      return this.varint64(BigInt.asUintN(64, v));
+   }
+
+   sint64(v: bigint): this {
+      v = BigInt.asIntN(64, v);
+      return this.varint64(BigInt.asUintN(64, (v << 1n) ^ (v >> 63n)));
+   }
+
+   bool(v: boolean): this {
+      return this.uint32(v ? 1 : 0);
+   }
+
+   fixed32(v: number): this {
+      this.reserve(4);
+      this.view.setUint32(this.pos, v >>> 0, true);
+      this.pos += 4;
+      return this;
+   }
+
+   sfixed32(v: number): this {
+      this.reserve(4);
+      this.view.setInt32(this.pos, v | 0, true);
+      this.pos += 4;
+      return this;
+   }
+
+   fixed64(v: bigint): this {
+      this.reserve(8);
+      this.view.setBigUint64(this.pos, BigInt.asUintN(64, v), true);
+      this.pos += 8;
+      return this;
+   }
+
+   sfixed64(v: bigint): this {
+      this.reserve(8);
+      this.view.setBigInt64(this.pos, BigInt.asIntN(64, v), true);
+      this.pos += 8;
+      return this;
+   }
+
+   float(v: number): this {
+      this.reserve(4);
+      this.view.setFloat32(this.pos, v, true);
+      this.pos += 4;
+      return this;
+   }
+
+   double(v: number): this {
+      this.reserve(8);
+      this.view.setFloat64(this.pos, v, true);
+      this.pos += 8;
+      return this;
+   }
+
+   /** Writes length-prefixed bytes. */
+   bytes(v: Uint8Array): this {
+      this.uint32(v.length);
+      return this.raw(v);
+   }
+
+   /** Writes a length-prefixed UTF-8 string. */
+   string(v: string): this {
+      return this.bytes(encoder.encode(v));
+   }
+
+   /** Writes bytes as they are, without a length prefix. */
+   raw(v: Uint8Array): this {
+      this.reserve(v.length);
+      this.buf.set(v, this.pos);
+      this.pos += v.length;
+      return this;
+   }
+
+   /** Starts a length-delimited value whose length isn't known yet. */
+   fork(): this {
+      this.forks.push(this.pos);
+      return this;
+   }
+
+   /** Ends the value started by the matching `fork()`, prefixing its length. */
+   ldelim(): this {
+      const start = this.forks.pop();
+      if (start === undefined) {
+         throw new Error("ldelim without matching fork");
+      }
+      const value = this.buf.slice(start, this.pos);
+      this.pos = start;
+      return this.bytes(value);
+   }
+
+   /** Returns the bytes written so far. */
+   finish(): Uint8Array {
+      if (this.forks.length > 0) {
+         throw new Error("finish with unmatched fork");
+      }
+      return this.buf.slice(0, this.pos);
+   }
+
+   private varint64(v: bigint): this {
+      this.reserve(10);
+      while (v > 127n) {
+         this.buf[this.pos++] = Number(v & 127n) | 128;
+         v >>= 7n;
+      }
+      this.buf[this.pos++] = Number(v);
+      return this;
+   }
+
+   /** Grows the buffer so that at least n more bytes fit. */
+   private reserve(n: number): void {
+      if (this.pos + n <= this.buf.length) {
+         return;
+      }
+      let size = this.buf.length * 2;
+      while (size < this.pos + n) {
+         size *= 2;
+      }
+      const buf = new Uint8Array(size);
+      buf.set(this.buf.subarray(0, this.pos));
+      this.buf = buf;
+      this.view = new DataView(buf.buffer);
+   }
+}
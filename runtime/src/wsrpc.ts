@@ -0,0 +1,253 @@
+import { Reader } from "./reader";
+import { Method, Transport } from "./rpc";
+import { WireType } from "./wire";
+import { Writer } from "./writer";
+
+/** Frame is one WebSocket message, as described by wsrpc.proto. */
+interface Frame {
+   call: number;
+   service: string;
+   method: number;
+   payload?: Uint8Array;
+   end: boolean;
+   error: string;
+   cancel: boolean;
+}
+
+function encodeFrame(f: Frame): Uint8Array {
+   const w = new Writer();
+   w.tag(1, WireType.Varint).uint32(f.call);
+   if (f.method !== 0) {
+      w.tag(2, WireType.Varint).uint32(f.method);
+   }
+   if (f.payload !== undefined) {
+      w.tag(3, WireType.Bytes).bytes(f.payload);
+   }
+   if (f.end) {
+      w.tag(4, WireType.Varint).bool(true);
+   }
+   if (f.error !== "") {
+      w.tag(5, WireType.Bytes).string(f.error);
+   }
+   if (f.service !== "") {
+      w.tag(6, WireType.Bytes).string(f.service);
+   }
+   if (f.cancel) {
+      w.tag(7, WireType.Varint).bool(true);
+   }
+   return w.finish();
+}
+
+function decodeFrame(bytes: Uint8Array): Frame {
+   const r = new Reader(bytes);
+   const f: Frame = { call: 0, service: "", method: 0, end: false, error: "", cancel: false };
+   while (r.pos < r.len) {
+      const t = r.uint32();
+      switch (t >>> 3) {
+         case 1:
+            f.call = r.uint32();
+            break;
+         case 2:
+            f.method = r.uint32();
+            break;
+         case 3:
+            f.payload = r.bytes();
+            break;
+         case 4:
+            f.end = r.bool();
+            break;
+         case 5:
+            f.error = r.string();
+            break;
+         case 6:
+            f.service = r.string();
+            break;
+         case 7:
+            f.cancel = r.bool();
+            break;
+         default:
+            r.skip(t & 7, t >>> 3);
+      }
+   }
+   return f;
+}
+
+/** Call queues the response payloads of one call until they are consumed. */
+class Call {
+   private readonly queue: Uint8Array[] = [];
+   /** The server ended the call, or the socket closed. */
+   done = false;
+   private error?: Error;
+   private wake?: () => void;
+
+   push(payload: Uint8Array): void {
+      this.queue.push(payload);
+      this.signal();
+   }
+
+   finish(error?: Error): void {
+      this.done = true;
+      this.error = error;
+      this.signal();
+   }
+
+   async *payloads(): AsyncGenerator<Uint8Array> {
+      for (;;) {
+         const payload = this.queue.shift();
+         if (payload !== undefined) {
+            yield payload;
+         } else if (this.error !== undefined) {
+            throw this.error;
+         } else if (this.done) {
+            return;
+         } else {
+            await new Promise<void>((resolve) => (this.wake = resolve));
+         }
+      }
+   }
+
+   private signal(): void {
+      const wake = this.wake;
+      this.wake = undefined;
+      wake?.();
+   }
+}
+
+/** Returns a frame the client sends in the call to the method. */
+function frame<I, O>(call: number, method: Method<I, O>, f: Partial<Frame>): Frame {
+   return { call, service: method.service, method: method.id, end: false, error: "", cancel: false, ...f };
+}
+
+async function* once<T>(value: T): AsyncGenerator<T> {
+   yield value;
+}
+
+async function single<T>(values: AsyncIterable<T>): Promise<T> {
+   for await (const v of values) {
+      return v;
+   }
+   throw new Error("call ended without a response");
+}
+
+/**
+ * WebSocketTransport multiplexes the calls of any number of clients over one
+ * WebSocket. Each request is framed with its service name, its method ID and
+ * a call ID that the server echoes, so responses are matched to their call.
+ * A call whose responses the caller stops reading early is canceled. Once the
+ * socket closes, the calls in progress and any made later fail.
+ */
+export class WebSocketTransport implements Transport {
+   private readonly calls = new Map<number, Call>();
+   private readonly ready: Promise<void>;
+   private nextCall = 1;
+   /** Why no more calls can be made, once the socket has closed. */
+   private closed?: Error;
+
+   constructor(private readonly socket: WebSocket) {
+      socket.binaryType = "arraybuffer";
+      socket.addEventListener("message", (e: MessageEvent) => this.receive(new Uint8Array(e.data)));
+      socket.addEventListener("close", () => this.closeAll(new Error("WebSocket closed")));
+      if (socket.readyState === WebSocket.CLOSING || socket.readyState === WebSocket.CLOSED) {
+         this.closed = new Error("WebSocket closed");
+      }
+      this.ready =
+         socket.readyState === WebSocket.CONNECTING
+            ? new Promise((resolve, reject) => {
+                 socket.addEventListener("open", () => resolve());
+                 socket.addEventListener("error", () => reject(new Error("WebSocket failed to open")));
+              })
+            : Promise.resolve();
+      // A failed open is reported to the calls that wait for it, and is not
+      // an error while there are none.
+      this.ready.catch(() => undefined);
+   }
+
+   unary<I, O>(method: Method<I, O>, request: I): Promise<O> {
+      return single(this.call(method, once(request)));
+   }
+
+   serverStream<I, O>(method: Method<I, O>, request: I): AsyncIterable<O> {
+      return this.call(method, once(request));
+   }
+
+   clientStream<I, O>(method: Method<I, O>, requests: AsyncIterable<I>): Promise<O> {
+      return single(this.call(method, requests));
+   }
+
+   bidiStream<I, O>(method: Method<I, O>, requests: AsyncIterable<I>): AsyncIterable<O> {
+      return this.call(method, requests);
+   }
+
+   private async *call<I, O>(method: Method<I, O>, requests: AsyncIterable<I>): AsyncGenerator<O> {
+      await this.ready;
+      if (this.closed !== undefined) {
+         throw this.closed;
+      }
+      const id = this.nextCall++;
+      const call = new Call();
+      this.calls.set(id, call);
+      this.sendAll(id, method, requests).catch((err: Error) => {
+         if (this.calls.has(id)) {
+            this.send(frame(id, method, { end: true, error: String(err) }));
+         }
+         call.finish(err);
+      });
+      try {
+         for await (const payload of call.payloads()) {
+            yield method.decode(payload);
+         }
+      } finally {
+         this.calls.delete(id);
+         if (!call.done && method.serverStreaming) {
+            // The caller stopped reading the stream early, so the server must
+            // stop too. Other calls end with their single response.
+            this.send(frame(id, method, { end: true, cancel: true }));
+         }
+      }
+   }
+
+   private async sendAll<I, O>(id: number, method: Method<I, O>, requests: AsyncIterable<I>): Promise<void> {
+      for await (const request of requests) {
+         if (!this.calls.has(id)) {
+            // The call was canceled.
+            return;
+         }
+         this.send(frame(id, method, { payload: method.encode(request) }));
+      }
+      if (this.calls.has(id)) {
+         this.send(frame(id, method, { end: true }));
+      }
+   }
+
+   private send(f: Frame): void {
+      if (this.closed !== undefined) {
+         throw this.closed;
+      }
+      this.socket.send(encodeFrame(f));
+   }
+
+   private receive(bytes: Uint8Array): void {
+      const f = decodeFrame(bytes);
+      const call = this.calls.get(f.call);
+      if (call === undefined) {
+         // The call was abandoned by its caller.
+         return;
+      }
+      if (f.payload !== undefined) {
+         call.push(f.payload);
+      }
+      if (f.error !== "") {
+         call.finish(new Error(f.error));
+      } else if (f.end) {
+         call.finish();
+      }
+   }
+
+   private closeAll(err: Error): void {
+      this.closed = err;
+      for (const call of this.calls.values()) {
+         call.finish(err);
+      }
+      this.calls.clear();
+   }
+}